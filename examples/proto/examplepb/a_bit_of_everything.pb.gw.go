@@ -22,7 +22,6 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -252,10 +251,16 @@ func request_ABitOfEverythingService_Create_0(ctx context.Context, marshaler run
 
 	protoReq.NestedPathEnumValue = pathenum.MessagePathEnum_NestedPathEnum(e)
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_ABitOfEverythingService_Create_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_ABitOfEverythingService_Create_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_ABitOfEverythingService_ABitOfEverything(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Create(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -269,6 +274,10 @@ func request_ABitOfEverythingService_CreateBody_0(ctx context.Context, marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.CreateBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -296,6 +305,10 @@ func request_ABitOfEverythingService_Lookup_0(ctx context.Context, marshaler run
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Lookup(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -327,6 +340,10 @@ func request_ABitOfEverythingService_Update_0(ctx context.Context, marshaler run
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Update(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -354,6 +371,10 @@ func request_ABitOfEverythingService_Delete_0(ctx context.Context, marshaler run
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Delete(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -385,10 +406,16 @@ func request_ABitOfEverythingService_GetQuery_0(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_ABitOfEverythingService_GetQuery_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_ABitOfEverythingService_GetQuery_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_ABitOfEverythingService_ABitOfEverything(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.GetQuery(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -588,6 +615,10 @@ func request_ABitOfEverythingService_GetRepeatedQuery_0(ctx context.Context, mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "path_repeated_sint64_value", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.GetRepeatedQuery(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -615,6 +646,10 @@ func request_ABitOfEverythingService_Echo_0(ctx context.Context, marshaler runti
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "value", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -628,6 +663,10 @@ func request_ABitOfEverythingService_Echo_1(ctx context.Context, marshaler runti
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -641,10 +680,16 @@ func request_ABitOfEverythingService_Echo_2(ctx context.Context, marshaler runti
 	var protoReq sub.StringMessage
 	var metadata runtime.ServerMetadata
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_ABitOfEverythingService_Echo_2); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_ABitOfEverythingService_Echo_2, func(fieldPath, values []string) error {
+		return runtime.PopulateFieldValuesFromPath(ctx, &protoReq, fieldPath, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -676,6 +721,10 @@ func request_ABitOfEverythingService_DeepPathEcho_0(ctx context.Context, marshal
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "single_nested.name", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.DeepPathEcho(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -685,6 +734,10 @@ func request_ABitOfEverythingService_Timeout_0(ctx context.Context, marshaler ru
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Timeout(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -694,6 +747,10 @@ func request_ABitOfEverythingService_ErrorWithDetails_0(ctx context.Context, mar
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.ErrorWithDetails(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -725,6 +782,10 @@ func request_ABitOfEverythingService_GetMessageWithBody_0(ctx context.Context, m
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "id", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.GetMessageWithBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -756,15 +817,315 @@ func request_ABitOfEverythingService_PostWithEmptyBody_0(ctx context.Context, ma
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "name", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.PostWithEmptyBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
 }
 
+func populateQueryParameters_ABitOfEverythingService_ABitOfEverything(ctx context.Context, root proto.Message, msg *ABitOfEverything, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "single_nested", "singleNested":
+		if i == len(fieldPath)-1 {
+			break
+		}
+		if msg.SingleNested == nil {
+			msg.SingleNested = &ABitOfEverything_Nested{}
+		}
+		return populateQueryParameters_ABitOfEverythingService_ABitOfEverything_Nested(ctx, root, msg.SingleNested, fieldPath, i+1, values)
+	case "uuid":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Uuid = v
+		return nil
+	case "float_value", "floatValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Float32(values[0])
+		if err != nil {
+			return err
+		}
+		msg.FloatValue = v
+		return nil
+	case "double_value", "doubleValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Float64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.DoubleValue = v
+		return nil
+	case "int64_value", "int64Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Int64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Int64Value = v
+		return nil
+	case "uint64_value", "uint64Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Uint64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Uint64Value = v
+		return nil
+	case "int32_value", "int32Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Int32(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Int32Value = v
+		return nil
+	case "fixed64_value", "fixed64Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Uint64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Fixed64Value = v
+		return nil
+	case "fixed32_value", "fixed32Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Uint32(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Fixed32Value = v
+		return nil
+	case "bool_value", "boolValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Bool(values[0])
+		if err != nil {
+			return err
+		}
+		msg.BoolValue = v
+		return nil
+	case "string_value", "stringValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.StringValue = v
+		return nil
+	case "bytes_value", "bytesValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Bytes(values[0])
+		if err != nil {
+			return err
+		}
+		msg.BytesValue = v
+		return nil
+	case "uint32_value", "uint32Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Uint32(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Uint32Value = v
+		return nil
+	case "enum_value", "enumValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		e, ok := NumericEnum_value[values[0]]
+		if !ok {
+			break
+		}
+		v := NumericEnum(e)
+		msg.EnumValue = v
+		return nil
+	case "path_enum_value", "pathEnumValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		e, ok := pathenum.PathEnum_value[values[0]]
+		if !ok {
+			break
+		}
+		v := pathenum.PathEnum(e)
+		msg.PathEnumValue = v
+		return nil
+	case "nested_path_enum_value", "nestedPathEnumValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		e, ok := pathenum.MessagePathEnum_NestedPathEnum_value[values[0]]
+		if !ok {
+			break
+		}
+		v := pathenum.MessagePathEnum_NestedPathEnum(e)
+		msg.NestedPathEnumValue = v
+		return nil
+	case "sfixed32_value", "sfixed32Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Int32(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Sfixed32Value = v
+		return nil
+	case "sfixed64_value", "sfixed64Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Int64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Sfixed64Value = v
+		return nil
+	case "sint32_value", "sint32Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Int32(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Sint32Value = v
+		return nil
+	case "sint64_value", "sint64Value":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Int64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Sint64Value = v
+		return nil
+	case "repeated_string_value", "repeatedStringValue":
+		if i != len(fieldPath)-1 {
+			break
+		}
+		s := make([]string, len(values))
+		for j, value := range values {
+			v, err := runtime.String(value)
+			if err != nil {
+				return err
+			}
+			s[j] = v
+		}
+		msg.RepeatedStringValue = s
+		return nil
+	case "oneof_string":
+		if i != len(fieldPath)-1 || len(values) != 1 || msg.OneofValue != nil {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.OneofValue = &ABitOfEverything_OneofString{OneofString: v}
+		return nil
+	case "nonConventionalNameValue":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.NonConventionalNameValue = v
+		return nil
+	case "repeated_enum_value", "repeatedEnumValue":
+		if i != len(fieldPath)-1 {
+			break
+		}
+		s := make([]NumericEnum, len(values))
+		for j, value := range values {
+			e, ok := NumericEnum_value[value]
+			if !ok {
+				return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+			}
+			s[j] = NumericEnum(e)
+		}
+		msg.RepeatedEnumValue = s
+		return nil
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
+func populateQueryParameters_ABitOfEverythingService_ABitOfEverything_Nested(ctx context.Context, root proto.Message, msg *ABitOfEverything_Nested, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "name":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Name = v
+		return nil
+	case "amount":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Uint32(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Amount = v
+		return nil
+	case "ok":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		e, ok := ABitOfEverything_Nested_DeepEnum_value[values[0]]
+		if !ok {
+			break
+		}
+		v := ABitOfEverything_Nested_DeepEnum(e)
+		msg.Ok = v
+		return nil
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
 func request_CamelCaseServiceName_Empty_0(ctx context.Context, marshaler runtime.Marshaler, client CamelCaseServiceNameClient, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Empty(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -780,14 +1141,14 @@ func RegisterABitOfEverythingServiceHandlerFromEndpoint(ctx context.Context, mux
 	defer func() {
 		if err != nil {
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 			return
 		}
 		go func() {
 			<-ctx.Done()
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 		}()
 	}()
@@ -811,6 +1172,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("POST", pattern_ABitOfEverythingService_Create_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Create")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -840,6 +1202,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("POST", pattern_ABitOfEverythingService_CreateBody_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/CreateBody")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -869,6 +1232,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("GET", pattern_ABitOfEverythingService_Lookup_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Lookup")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -898,6 +1262,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("PUT", pattern_ABitOfEverythingService_Update_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Update")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -927,6 +1292,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("DELETE", pattern_ABitOfEverythingService_Delete_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Delete")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -956,6 +1322,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("GET", pattern_ABitOfEverythingService_GetQuery_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/GetQuery")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -985,6 +1352,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("GET", pattern_ABitOfEverythingService_GetRepeatedQuery_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/GetRepeatedQuery")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1014,6 +1382,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("GET", pattern_ABitOfEverythingService_Echo_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1043,6 +1412,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("POST", pattern_ABitOfEverythingService_Echo_1, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1072,6 +1442,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("GET", pattern_ABitOfEverythingService_Echo_2, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1101,6 +1472,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("POST", pattern_ABitOfEverythingService_DeepPathEcho_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/DeepPathEcho")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1130,6 +1502,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("GET", pattern_ABitOfEverythingService_Timeout_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/Timeout")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1159,6 +1532,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("GET", pattern_ABitOfEverythingService_ErrorWithDetails_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/ErrorWithDetails")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1188,6 +1562,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("POST", pattern_ABitOfEverythingService_GetMessageWithBody_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/GetMessageWithBody")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1217,6 +1592,7 @@ func RegisterABitOfEverythingServiceHandlerClient(ctx context.Context, mux *runt
 	mux.Handle("POST", pattern_ABitOfEverythingService_PostWithEmptyBody_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ABitOfEverythingService/PostWithEmptyBody")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1320,14 +1696,14 @@ func RegisterCamelCaseServiceNameHandlerFromEndpoint(ctx context.Context, mux *r
 	defer func() {
 		if err != nil {
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 			return
 		}
 		go func() {
 			<-ctx.Done()
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 		}()
 	}()
@@ -1351,6 +1727,7 @@ func RegisterCamelCaseServiceNameHandlerClient(ctx context.Context, mux *runtime
 	mux.Handle("GET", pattern_CamelCaseServiceName_Empty_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.CamelCaseServiceName/Empty")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -18,7 +18,6 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -54,10 +53,16 @@ func request_EchoService_Echo_0(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "id", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_EchoService_Echo_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_EchoService_Echo_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_EchoService_SimpleMessage(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -100,10 +105,16 @@ func request_EchoService_Echo_1(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "num", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_EchoService_Echo_1); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_EchoService_Echo_1, func(fieldPath, values []string) error {
+		return populateQueryParameters_EchoService_SimpleMessage(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -162,10 +173,16 @@ func request_EchoService_Echo_2(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "lang", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_EchoService_Echo_2); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_EchoService_Echo_2, func(fieldPath, values []string) error {
+		return populateQueryParameters_EchoService_SimpleMessage(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -224,10 +241,16 @@ func request_EchoService_Echo_3(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "status.note", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_EchoService_Echo_3); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_EchoService_Echo_3, func(fieldPath, values []string) error {
+		return populateQueryParameters_EchoService_SimpleMessage(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -259,10 +282,16 @@ func request_EchoService_Echo_4(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "no.note", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_EchoService_Echo_4); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_EchoService_Echo_4, func(fieldPath, values []string) error {
+		return populateQueryParameters_EchoService_SimpleMessage(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -276,6 +305,10 @@ func request_EchoService_EchoBody_0(ctx context.Context, marshaler runtime.Marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.EchoBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -289,15 +322,111 @@ func request_EchoService_EchoDelete_0(ctx context.Context, marshaler runtime.Mar
 	var protoReq SimpleMessage
 	var metadata runtime.ServerMetadata
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_EchoService_EchoDelete_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_EchoService_EchoDelete_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_EchoService_SimpleMessage(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.EchoDelete(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
 }
 
+func populateQueryParameters_EchoService_SimpleMessage(ctx context.Context, root proto.Message, msg *SimpleMessage, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "id":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Id = v
+		return nil
+	case "num":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.Int64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Num = v
+		return nil
+	case "line_num":
+		if i != len(fieldPath)-1 || len(values) != 1 || msg.Code != nil {
+			break
+		}
+		v, err := runtime.Int64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Code = &SimpleMessage_LineNum{LineNum: v}
+		return nil
+	case "lang":
+		if i != len(fieldPath)-1 || len(values) != 1 || msg.Code != nil {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Code = &SimpleMessage_Lang{Lang: v}
+		return nil
+	case "status":
+		if i == len(fieldPath)-1 {
+			break
+		}
+		if msg.Status == nil {
+			msg.Status = &Embedded{}
+		}
+		return populateQueryParameters_EchoService_Embedded(ctx, root, msg.Status, fieldPath, i+1, values)
+	case "en":
+		if i != len(fieldPath)-1 || len(values) != 1 || msg.Ext != nil {
+			break
+		}
+		v, err := runtime.Int64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Ext = &SimpleMessage_En{En: v}
+		return nil
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
+func populateQueryParameters_EchoService_Embedded(ctx context.Context, root proto.Message, msg *Embedded, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "progress":
+		if i != len(fieldPath)-1 || len(values) != 1 || msg.Mark != nil {
+			break
+		}
+		v, err := runtime.Int64(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Mark = &Embedded_Progress{Progress: v}
+		return nil
+	case "note":
+		if i != len(fieldPath)-1 || len(values) != 1 || msg.Mark != nil {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Mark = &Embedded_Note{Note: v}
+		return nil
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
 // RegisterEchoServiceHandlerFromEndpoint is same as RegisterEchoServiceHandler but
 // automatically dials to "endpoint" and closes the connection when "ctx" gets done.
 func RegisterEchoServiceHandlerFromEndpoint(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) (err error) {
@@ -308,14 +437,14 @@ func RegisterEchoServiceHandlerFromEndpoint(ctx context.Context, mux *runtime.Se
 	defer func() {
 		if err != nil {
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 			return
 		}
 		go func() {
 			<-ctx.Done()
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 		}()
 	}()
@@ -339,6 +468,7 @@ func RegisterEchoServiceHandlerClient(ctx context.Context, mux *runtime.ServeMux
 	mux.Handle("POST", pattern_EchoService_Echo_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.EchoService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -368,6 +498,7 @@ func RegisterEchoServiceHandlerClient(ctx context.Context, mux *runtime.ServeMux
 	mux.Handle("GET", pattern_EchoService_Echo_1, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.EchoService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -397,6 +528,7 @@ func RegisterEchoServiceHandlerClient(ctx context.Context, mux *runtime.ServeMux
 	mux.Handle("GET", pattern_EchoService_Echo_2, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.EchoService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -426,6 +558,7 @@ func RegisterEchoServiceHandlerClient(ctx context.Context, mux *runtime.ServeMux
 	mux.Handle("GET", pattern_EchoService_Echo_3, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.EchoService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -455,6 +588,7 @@ func RegisterEchoServiceHandlerClient(ctx context.Context, mux *runtime.ServeMux
 	mux.Handle("GET", pattern_EchoService_Echo_4, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.EchoService/Echo")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -484,6 +618,7 @@ func RegisterEchoServiceHandlerClient(ctx context.Context, mux *runtime.ServeMux
 	mux.Handle("POST", pattern_EchoService_EchoBody_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.EchoService/EchoBody")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -513,6 +648,7 @@ func RegisterEchoServiceHandlerClient(ctx context.Context, mux *runtime.ServeMux
 	mux.Handle("DELETE", pattern_EchoService_EchoDelete_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.EchoService/EchoDelete")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
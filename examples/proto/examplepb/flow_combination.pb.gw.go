@@ -18,7 +18,6 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -32,6 +31,10 @@ func request_FlowCombination_RpcEmptyRpc_0(ctx context.Context, marshaler runtim
 	var protoReq EmptyProto
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcEmptyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -41,6 +44,10 @@ func request_FlowCombination_RpcEmptyStream_0(ctx context.Context, marshaler run
 	var protoReq EmptyProto
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcEmptyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -58,7 +65,7 @@ func request_FlowCombination_StreamEmptyRpc_0(ctx context.Context, marshaler run
 	var metadata runtime.ServerMetadata
 	stream, err := client.StreamEmptyRpc(ctx)
 	if err != nil {
-		grpclog.Infof("Failed to start streaming: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to start streaming: %v", err)
 		return nil, metadata, err
 	}
 	dec := marshaler.NewDecoder(req.Body)
@@ -69,22 +76,26 @@ func request_FlowCombination_StreamEmptyRpc_0(ctx context.Context, marshaler run
 			break
 		}
 		if err != nil {
-			grpclog.Infof("Failed to decode request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to decode request: %v", err)
 			return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 		}
+		if err = runtime.ValidateRequest(ctx, &protoReq); err != nil {
+			return nil, metadata, err
+		}
 		if err = stream.Send(&protoReq); err != nil {
-			grpclog.Infof("Failed to send request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to send request: %v", err)
 			return nil, metadata, err
 		}
+		runtime.StreamMessageSent(ctx)
 	}
 
 	if err := stream.CloseSend(); err != nil {
-		grpclog.Infof("Failed to terminate client stream: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", err)
 		return nil, metadata, err
 	}
 	header, err := stream.Header()
 	if err != nil {
-		grpclog.Infof("Failed to get header from client: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to get header from client: %v", err)
 		return nil, metadata, err
 	}
 	metadata.HeaderMD = header
@@ -99,7 +110,7 @@ func request_FlowCombination_StreamEmptyStream_0(ctx context.Context, marshaler
 	var metadata runtime.ServerMetadata
 	stream, err := client.StreamEmptyStream(ctx)
 	if err != nil {
-		grpclog.Infof("Failed to start streaming: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to start streaming: %v", err)
 		return nil, metadata, err
 	}
 	dec := marshaler.NewDecoder(req.Body)
@@ -110,18 +121,22 @@ func request_FlowCombination_StreamEmptyStream_0(ctx context.Context, marshaler
 			return err
 		}
 		if err != nil {
-			grpclog.Infof("Failed to decode request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to decode request: %v", err)
+			return err
+		}
+		if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
 			return err
 		}
 		if err := stream.Send(&protoReq); err != nil {
-			grpclog.Infof("Failed to send request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to send request: %v", err)
 			return err
 		}
+		runtime.StreamMessageSent(ctx)
 		return nil
 	}
 	if err := handleSend(); err != nil {
 		if cerr := stream.CloseSend(); cerr != nil {
-			grpclog.Infof("Failed to terminate client stream: %v", cerr)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", cerr)
 		}
 		if err == io.EOF {
 			return stream, metadata, nil
@@ -135,12 +150,12 @@ func request_FlowCombination_StreamEmptyStream_0(ctx context.Context, marshaler
 			}
 		}
 		if err := stream.CloseSend(); err != nil {
-			grpclog.Infof("Failed to terminate client stream: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", err)
 		}
 	}()
 	header, err := stream.Header()
 	if err != nil {
-		grpclog.Infof("Failed to get header from client: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to get header from client: %v", err)
 		return nil, metadata, err
 	}
 	metadata.HeaderMD = header
@@ -155,6 +170,10 @@ func request_FlowCombination_RpcBodyRpc_0(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -204,6 +223,10 @@ func request_FlowCombination_RpcBodyRpc_1(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "c", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -217,10 +240,16 @@ func request_FlowCombination_RpcBodyRpc_2(ctx context.Context, marshaler runtime
 	var protoReq NonEmptyProto
 	var metadata runtime.ServerMetadata
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyRpc_2); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyRpc_2, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -263,6 +292,10 @@ func request_FlowCombination_RpcBodyRpc_3(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "b", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -280,10 +313,16 @@ func request_FlowCombination_RpcBodyRpc_4(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyRpc_4); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyRpc_4, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -319,10 +358,16 @@ func request_FlowCombination_RpcBodyRpc_5(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyRpc_5); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyRpc_5, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -354,10 +399,16 @@ func request_FlowCombination_RpcBodyRpc_6(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyRpc_6); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyRpc_6, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -389,10 +440,16 @@ func request_FlowCombination_RpcPathSingleNestedRpc_0(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a.str", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathSingleNestedRpc_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathSingleNestedRpc_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_SingleNestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcPathSingleNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -439,10 +496,16 @@ func request_FlowCombination_RpcPathNestedRpc_0(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "b", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathNestedRpc_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathNestedRpc_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcPathNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -474,10 +537,16 @@ func request_FlowCombination_RpcPathNestedRpc_1(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a.str", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathNestedRpc_1); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathNestedRpc_1, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcPathNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -513,10 +582,16 @@ func request_FlowCombination_RpcPathNestedRpc_2(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a.str", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathNestedRpc_2); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathNestedRpc_2, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.RpcPathNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -530,6 +605,10 @@ func request_FlowCombination_RpcBodyStream_0(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -587,6 +666,10 @@ func request_FlowCombination_RpcBodyStream_1(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "c", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -608,10 +691,16 @@ func request_FlowCombination_RpcBodyStream_2(ctx context.Context, marshaler runt
 	var protoReq NonEmptyProto
 	var metadata runtime.ServerMetadata
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyStream_2); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyStream_2, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -662,6 +751,10 @@ func request_FlowCombination_RpcBodyStream_3(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "b", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -687,10 +780,16 @@ func request_FlowCombination_RpcBodyStream_4(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyStream_4); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyStream_4, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -734,10 +833,16 @@ func request_FlowCombination_RpcBodyStream_5(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyStream_5); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyStream_5, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -777,10 +882,16 @@ func request_FlowCombination_RpcBodyStream_6(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcBodyStream_6); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcBodyStream_6, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NonEmptyProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -820,10 +931,16 @@ func request_FlowCombination_RpcPathSingleNestedStream_0(ctx context.Context, ma
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a.str", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathSingleNestedStream_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathSingleNestedStream_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_SingleNestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcPathSingleNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -878,10 +995,16 @@ func request_FlowCombination_RpcPathNestedStream_0(ctx context.Context, marshale
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "b", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathNestedStream_0); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathNestedStream_0, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcPathNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -921,10 +1044,16 @@ func request_FlowCombination_RpcPathNestedStream_1(ctx context.Context, marshale
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a.str", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathNestedStream_1); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathNestedStream_1, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcPathNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -968,10 +1097,16 @@ func request_FlowCombination_RpcPathNestedStream_2(ctx context.Context, marshale
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "a.str", err)
 	}
 
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_FlowCombination_RpcPathNestedStream_2); err != nil {
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_FlowCombination_RpcPathNestedStream_2, func(fieldPath, values []string) error {
+		return populateQueryParameters_FlowCombination_NestedProto(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.RpcPathNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -985,6 +1120,106 @@ func request_FlowCombination_RpcPathNestedStream_2(ctx context.Context, marshale
 
 }
 
+func populateQueryParameters_FlowCombination_NonEmptyProto(ctx context.Context, root proto.Message, msg *NonEmptyProto, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "a":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.A = v
+		return nil
+	case "b":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.B = v
+		return nil
+	case "c":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.C = v
+		return nil
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
+func populateQueryParameters_FlowCombination_SingleNestedProto(ctx context.Context, root proto.Message, msg *SingleNestedProto, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "a":
+		if i == len(fieldPath)-1 {
+			break
+		}
+		if msg.A == nil {
+			msg.A = &UnaryProto{}
+		}
+		return populateQueryParameters_FlowCombination_UnaryProto(ctx, root, msg.A, fieldPath, i+1, values)
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
+func populateQueryParameters_FlowCombination_NestedProto(ctx context.Context, root proto.Message, msg *NestedProto, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "a":
+		if i == len(fieldPath)-1 {
+			break
+		}
+		if msg.A == nil {
+			msg.A = &UnaryProto{}
+		}
+		return populateQueryParameters_FlowCombination_UnaryProto(ctx, root, msg.A, fieldPath, i+1, values)
+	case "b":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.B = v
+		return nil
+	case "c":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.C = v
+		return nil
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
+func populateQueryParameters_FlowCombination_UnaryProto(ctx context.Context, root proto.Message, msg *UnaryProto, fieldPath []string, i int, values []string) error {
+	switch fieldPath[i] {
+	case "str":
+		if i != len(fieldPath)-1 || len(values) != 1 {
+			break
+		}
+		v, err := runtime.String(values[0])
+		if err != nil {
+			return err
+		}
+		msg.Str = v
+		return nil
+	}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+
 // RegisterFlowCombinationHandlerFromEndpoint is same as RegisterFlowCombinationHandler but
 // automatically dials to "endpoint" and closes the connection when "ctx" gets done.
 func RegisterFlowCombinationHandlerFromEndpoint(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) (err error) {
@@ -995,14 +1230,14 @@ func RegisterFlowCombinationHandlerFromEndpoint(ctx context.Context, mux *runtim
 	defer func() {
 		if err != nil {
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 			return
 		}
 		go func() {
 			<-ctx.Done()
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 		}()
 	}()
@@ -1026,6 +1261,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcEmptyRpc_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcEmptyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1055,6 +1291,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcEmptyStream_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcEmptyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1084,6 +1321,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_StreamEmptyRpc_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/StreamEmptyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1113,6 +1351,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_StreamEmptyStream_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/StreamEmptyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1142,6 +1381,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyRpc_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1171,6 +1411,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyRpc_1, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1200,6 +1441,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyRpc_2, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1229,6 +1471,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyRpc_3, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1258,6 +1501,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyRpc_4, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1287,6 +1531,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyRpc_5, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1316,6 +1561,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyRpc_6, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1345,6 +1591,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathSingleNestedRpc_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathSingleNestedRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1374,6 +1621,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathNestedRpc_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathNestedRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1403,6 +1651,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathNestedRpc_1, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathNestedRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1432,6 +1681,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathNestedRpc_2, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathNestedRpc")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1461,6 +1711,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyStream_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1490,6 +1741,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyStream_1, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1519,6 +1771,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyStream_2, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1548,6 +1801,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyStream_3, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1577,6 +1831,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyStream_4, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1606,6 +1861,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyStream_5, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1635,6 +1891,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcBodyStream_6, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcBodyStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1664,6 +1921,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathSingleNestedStream_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathSingleNestedStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1693,6 +1951,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathNestedStream_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathNestedStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1722,6 +1981,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathNestedStream_1, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathNestedStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1751,6 +2011,7 @@ func RegisterFlowCombinationHandlerClient(ctx context.Context, mux *runtime.Serv
 	mux.Handle("POST", pattern_FlowCombination_RpcPathNestedStream_2, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.FlowCombination/RpcPathNestedStream")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
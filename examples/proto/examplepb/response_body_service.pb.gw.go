@@ -18,7 +18,6 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -50,6 +49,10 @@ func request_ResponseBodyService_GetResponseBody_0(ctx context.Context, marshale
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "data", err)
 	}
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	msg, err := client.GetResponseBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -65,14 +68,14 @@ func RegisterResponseBodyServiceHandlerFromEndpoint(ctx context.Context, mux *ru
 	defer func() {
 		if err != nil {
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 			return
 		}
 		go func() {
 			<-ctx.Done()
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 		}()
 	}()
@@ -96,6 +99,7 @@ func RegisterResponseBodyServiceHandlerClient(ctx context.Context, mux *runtime.
 	mux.Handle("GET", pattern_ResponseBodyService_GetResponseBody_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.ResponseBodyService/GetResponseBody")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
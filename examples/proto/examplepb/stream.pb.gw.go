@@ -20,7 +20,6 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -34,7 +33,7 @@ func request_StreamService_BulkCreate_0(ctx context.Context, marshaler runtime.M
 	var metadata runtime.ServerMetadata
 	stream, err := client.BulkCreate(ctx)
 	if err != nil {
-		grpclog.Infof("Failed to start streaming: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to start streaming: %v", err)
 		return nil, metadata, err
 	}
 	dec := marshaler.NewDecoder(req.Body)
@@ -45,22 +44,26 @@ func request_StreamService_BulkCreate_0(ctx context.Context, marshaler runtime.M
 			break
 		}
 		if err != nil {
-			grpclog.Infof("Failed to decode request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to decode request: %v", err)
 			return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 		}
+		if err = runtime.ValidateRequest(ctx, &protoReq); err != nil {
+			return nil, metadata, err
+		}
 		if err = stream.Send(&protoReq); err != nil {
-			grpclog.Infof("Failed to send request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to send request: %v", err)
 			return nil, metadata, err
 		}
+		runtime.StreamMessageSent(ctx)
 	}
 
 	if err := stream.CloseSend(); err != nil {
-		grpclog.Infof("Failed to terminate client stream: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", err)
 		return nil, metadata, err
 	}
 	header, err := stream.Header()
 	if err != nil {
-		grpclog.Infof("Failed to get header from client: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to get header from client: %v", err)
 		return nil, metadata, err
 	}
 	metadata.HeaderMD = header
@@ -75,6 +78,10 @@ func request_StreamService_List_0(ctx context.Context, marshaler runtime.Marshal
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
 	stream, err := client.List(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -92,7 +99,7 @@ func request_StreamService_BulkEcho_0(ctx context.Context, marshaler runtime.Mar
 	var metadata runtime.ServerMetadata
 	stream, err := client.BulkEcho(ctx)
 	if err != nil {
-		grpclog.Infof("Failed to start streaming: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to start streaming: %v", err)
 		return nil, metadata, err
 	}
 	dec := marshaler.NewDecoder(req.Body)
@@ -103,18 +110,22 @@ func request_StreamService_BulkEcho_0(ctx context.Context, marshaler runtime.Mar
 			return err
 		}
 		if err != nil {
-			grpclog.Infof("Failed to decode request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to decode request: %v", err)
+			return err
+		}
+		if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
 			return err
 		}
 		if err := stream.Send(&protoReq); err != nil {
-			grpclog.Infof("Failed to send request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to send request: %v", err)
 			return err
 		}
+		runtime.StreamMessageSent(ctx)
 		return nil
 	}
 	if err := handleSend(); err != nil {
 		if cerr := stream.CloseSend(); cerr != nil {
-			grpclog.Infof("Failed to terminate client stream: %v", cerr)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", cerr)
 		}
 		if err == io.EOF {
 			return stream, metadata, nil
@@ -128,12 +139,12 @@ func request_StreamService_BulkEcho_0(ctx context.Context, marshaler runtime.Mar
 			}
 		}
 		if err := stream.CloseSend(); err != nil {
-			grpclog.Infof("Failed to terminate client stream: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", err)
 		}
 	}()
 	header, err := stream.Header()
 	if err != nil {
-		grpclog.Infof("Failed to get header from client: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to get header from client: %v", err)
 		return nil, metadata, err
 	}
 	metadata.HeaderMD = header
@@ -150,14 +161,14 @@ func RegisterStreamServiceHandlerFromEndpoint(ctx context.Context, mux *runtime.
 	defer func() {
 		if err != nil {
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 			return
 		}
 		go func() {
 			<-ctx.Done()
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 		}()
 	}()
@@ -181,6 +192,7 @@ func RegisterStreamServiceHandlerClient(ctx context.Context, mux *runtime.ServeM
 	mux.Handle("POST", pattern_StreamService_BulkCreate_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.StreamService/BulkCreate")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -210,6 +222,7 @@ func RegisterStreamServiceHandlerClient(ctx context.Context, mux *runtime.ServeM
 	mux.Handle("GET", pattern_StreamService_List_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.StreamService/List")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -239,6 +252,7 @@ func RegisterStreamServiceHandlerClient(ctx context.Context, mux *runtime.ServeM
 	mux.Handle("POST", pattern_StreamService_BulkEcho_0, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		ctx, cancel := context.WithCancel(req.Context())
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/grpc.gateway.examples.examplepb.StreamService/BulkEcho")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
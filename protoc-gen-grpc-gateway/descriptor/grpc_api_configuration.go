@@ -56,6 +56,7 @@ func registerHTTPRulesFromGrpcAPIService(registry *Registry, service *GrpcAPISer
 //
 // Note that for the purposes of the gateway generator we only consider a subset of all
 // available features google supports in their service descriptions.
+// The "limits" section for runtime.LoadLimitsFromYAML is ignored.
 func (r *Registry) LoadGrpcAPIServiceFromYAML(yamlFile string) error {
 	yamlFileContents, err := ioutil.ReadFile(yamlFile)
 	if err != nil {
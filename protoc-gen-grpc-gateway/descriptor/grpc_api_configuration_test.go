@@ -162,3 +162,29 @@ http:
 		t.Errorf("some.other.service has %v additional bindings when it should not have any. Got: %v", len(second.GetAdditionalBindings()), second.GetAdditionalBindings())
 	}
 }
+
+func TestLoadGrpcAPIServiceFromYAMLWithLimits(t *testing.T) {
+	// The limits section is read by runtime.LoadLimitsFromYAML and ignored here.
+	service, err := loadGrpcAPIServiceFromYAML([]byte(`
+type: google.api.Service
+config_version: 3
+
+http:
+ rules:
+ - selector: grpctest.YourService.Echo
+   post: /v1/myecho
+   body: "*"
+
+limits:
+- selector: grpctest.YourService.Echo
+  rate: 10
+  key: client_ip
+`), "limits")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(service.HTTP.GetRules()) != 1 {
+		t.Fatalf("Have %v rules instead of one. Got: %v", len(service.HTTP.GetRules()), service.HTTP.GetRules())
+	}
+}
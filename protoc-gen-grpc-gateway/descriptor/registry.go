@@ -57,6 +57,10 @@ type Registry struct {
 	// otherwise the original proto name is used. It's helpful for synchronizing the swagger definition
 	// with grpc-gateway response, if it uses json tags for marshaling.
 	useJSONNamesForFields bool
+
+	// generateSchemaValidation if true the gateway validates request messages against the JSON schema
+	// constraints in `openapiv2_field` and `openapiv2_schema` options.
+	generateSchemaValidation bool
 }
 
 type repeatedFieldSeparator struct {
@@ -350,6 +354,18 @@ func (r *Registry) IsAllowRepeatedFieldsInBody() bool {
 	return r.allowRepeatedFieldsInBody
 }
 
+// SetGenerateSchemaValidation controls whether the gateway validates request messages against
+// the JSON schema constraints in `openapiv2_field` and `openapiv2_schema` options or not
+func (r *Registry) SetGenerateSchemaValidation(generate bool) {
+	r.generateSchemaValidation = generate
+}
+
+// IsGenerateSchemaValidation checks if the gateway validates request messages against
+// the JSON schema constraints in `openapiv2_field` and `openapiv2_schema` options or not
+func (r *Registry) IsGenerateSchemaValidation() bool {
+	return r.generateSchemaValidation
+}
+
 // GetRepeatedPathParamSeparator returns a rune spcifying how
 // path parameter repeated fields are separated.
 func (r *Registry) GetRepeatedPathParamSeparator() rune {
@@ -46,7 +46,6 @@ func New(reg *descriptor.Registry, useRequestContext bool, registerFuncSuffix, p
 		"golang.org/x/net/context",
 		"google.golang.org/grpc",
 		"google.golang.org/grpc/codes",
-		"google.golang.org/grpc/grpclog",
 		"google.golang.org/grpc/status",
 	} {
 		pkg := descriptor.GoPackage{
@@ -126,6 +125,13 @@ func (g *generator) generate(file *descriptor.File) (string, error) {
 		imports = append(imports, pkg)
 	}
 	for _, svc := range file.Services {
+		for _, pkg := range queryParamPopulatorImports(svc, g.reg) {
+			if pkg.Path == file.GoPkg.Path || pkgSeen[pkg.Path] {
+				continue
+			}
+			pkgSeen[pkg.Path] = true
+			imports = append(imports, pkg)
+		}
 		for _, m := range svc.Methods {
 			imports = append(imports, g.addEnumPathParamImports(file, m, pkgSeen)...)
 			pkg := m.RequestType.File.GoPkg
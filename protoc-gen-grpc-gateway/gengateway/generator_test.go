@@ -7,7 +7,9 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	protodescriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
 )
 
 func newExampleFileDescriptor() *descriptor.File {
@@ -98,6 +100,72 @@ func TestGenerateServiceWithoutBindings(t *testing.T) {
 	}
 }
 
+func TestGenerateQueryParamPopulatorImports(t *testing.T) {
+	const src = `
+		file_to_generate: "example.proto"
+		proto_file <
+			name: "types.proto"
+			package: "types"
+			syntax: "proto3"
+			options < go_package: "example.com/path/to/types;types_pb" >
+			message_type <
+				name: "Inner"
+				field < name: "color" json_name: "color" number: 1 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".types.Color" >
+			>
+			enum_type <
+				name: "Color"
+				value < name: "RED" number: 0 >
+			>
+		>
+		proto_file <
+			name: "example.proto"
+			package: "example"
+			dependency: "types.proto"
+			syntax: "proto3"
+			options < go_package: "example.com/path/to/example;example_pb" >
+			message_type <
+				name: "ExampleMessage"
+				field < name: "str" json_name: "str" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING >
+				field < name: "inner" json_name: "inner" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".types.Inner" >
+			>
+			service <
+				name: "ExampleService"
+				method < name: "Echo" input_type: ".example.ExampleMessage" output_type: ".example.ExampleMessage" >
+			>
+		>
+	`
+	var req plugin.CodeGeneratorRequest
+	if err := proto.UnmarshalText(src, &req); err != nil {
+		t.Fatalf("proto.UnmarshalText(%s, &req) failed with %v; want success", src, err)
+	}
+	reg := descriptor.NewRegistry()
+	reg.AddExternalHTTPRule(".example.ExampleService.Echo", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/example/{str}"},
+	})
+	if err := reg.Load(&req); err != nil {
+		t.Fatalf("reg.Load(%s) failed with %v; want success", src, err)
+	}
+	file, err := reg.LookupFile("example.proto")
+	if err != nil {
+		t.Fatalf("reg.LookupFile(%q) failed with %v; want success", "example.proto", err)
+	}
+
+	g := &generator{reg: reg}
+	got, err := g.generate(file)
+	if err != nil {
+		t.Fatalf("generate(%#v) failed with %v; want success", file, err)
+	}
+	for _, want := range []string{
+		`"example.com/path/to/types"`,
+		`msg *types_pb.Inner`,
+		`types_pb.Color_value[values[0]]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generate(%#v) = %s; want to contain %s", file, got, want)
+		}
+	}
+}
+
 func TestGenerateOutputPath(t *testing.T) {
 	cases := []struct {
 		file     *descriptor.File
@@ -0,0 +1,198 @@
+package gengateway
+
+import (
+	"strings"
+
+	protodescriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	gogen "github.com/golang/protobuf/protoc-gen-go/generator"
+	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
+)
+
+// queryParamPopulator describes a generated function which populates query parameters
+// into a message of a specific type without reflection.
+// Parameters the function does not know how to handle are passed to
+// runtime.PopulateFieldValuesFromPath so that the behavior stays the same as runtime.PopulateQueryParameters.
+type queryParamPopulator struct {
+	// FuncName is the name of the generated function.
+	FuncName string
+	// GoType is the go type of the message which the function populates.
+	GoType string
+	// Fields is the list of fields the function handles by itself.
+	Fields []queryParamField
+	// goPkgs is the list of go packages of the types the function refers to.
+	goPkgs []descriptor.GoPackage
+}
+
+// queryParamField describes how a generated populator sets a field.
+type queryParamField struct {
+	// Names is the list of parameter names which are mapped to the field.
+	Names []string
+	// GoName is the name of the field in the go struct.
+	GoName string
+	// Repeated is true if the field is a repeated field.
+	Repeated bool
+	// ConvertFunc is a go expression of the function which converts a string into the value of the field.
+	ConvertFunc string
+	// GoElemType is the go type of the elements of a repeated field.
+	GoElemType string
+	// Enum is the go type of the field if it is an enum field.
+	Enum string
+	// MessageFunc is the name of the populator of the field if it is a message field.
+	MessageFunc string
+	// MessageGoType is the go type of the field if it is a message field.
+	MessageGoType string
+	// Oneof is the name of the oneof field in the go struct if the field is a member of a oneof.
+	Oneof string
+	// OneofWrapper is the go type which wraps the field if the field is a member of a oneof.
+	OneofWrapper string
+}
+
+// isQueryParamPopulatable returns true if a populator can be generated for "msg".
+// proto2 messages, map entries and well known types are left to the reflection-based implementation in runtime.
+func isQueryParamPopulatable(msg *descriptor.Message) bool {
+	if msg == nil || msg.File == nil || msg.File.GetSyntax() != "proto3" {
+		return false
+	}
+	if msg.GetOptions().GetMapEntry() {
+		return false
+	}
+	return msg.File.GetPackage() != "google.protobuf"
+}
+
+// queryParamPopulatorName returns the name of the populator of "msg" generated for "svc".
+func queryParamPopulatorName(svc *descriptor.Service, msg *descriptor.Message) string {
+	ident := strings.Replace(msg.GoType(svc.File.GoPkg.Path), ".", "_", -1)
+	return "populateQueryParameters_" + svc.GetName() + "_" + ident
+}
+
+// queryParamPopulators returns the populators to be generated for "svc".
+// It covers the request messages of the bindings which accept query parameters and,
+// transitively, the messages of their singular message fields.
+func queryParamPopulators(svc *descriptor.Service, reg *descriptor.Registry) []*queryParamPopulator {
+	var (
+		queue []*descriptor.Message
+		seen  = make(map[string]bool)
+	)
+	enqueue := func(msg *descriptor.Message) {
+		if !seen[msg.FQMN()] {
+			seen[msg.FQMN()] = true
+			queue = append(queue, msg)
+		}
+	}
+	for _, meth := range svc.Methods {
+		if meth.GetClientStreaming() || !isQueryParamPopulatable(meth.RequestType) {
+			continue
+		}
+		for _, b := range meth.Bindings {
+			if (binding{Binding: b, Registry: reg}).HasQueryParam() {
+				enqueue(meth.RequestType)
+				break
+			}
+		}
+	}
+
+	var populators []*queryParamPopulator
+	for len(queue) > 0 {
+		msg := queue[0]
+		queue = queue[1:]
+		p := &queryParamPopulator{
+			FuncName: queryParamPopulatorName(svc, msg),
+			GoType:   msg.GoType(svc.File.GoPkg.Path),
+			goPkgs:   []descriptor.GoPackage{msg.File.GoPkg},
+		}
+		for _, f := range msg.Fields {
+			qf, ok := newQueryParamField(svc, msg, f, reg)
+			if !ok {
+				continue
+			}
+			if qf.MessageFunc != "" {
+				fieldMsg, _ := reg.LookupMsg("", f.GetTypeName())
+				enqueue(fieldMsg)
+			}
+			if qf.Enum != "" {
+				e, _ := reg.LookupEnum("", f.GetTypeName())
+				p.goPkgs = append(p.goPkgs, e.File.GoPkg)
+			}
+			p.Fields = append(p.Fields, qf)
+		}
+		populators = append(populators, p)
+	}
+	return populators
+}
+
+// queryParamPopulatorImports returns the go packages which the populators generated for "svc" refer to,
+// i.e. the packages of the messages they populate, of their enum fields and of the oneof wrappers.
+func queryParamPopulatorImports(svc *descriptor.Service, reg *descriptor.Registry) []descriptor.GoPackage {
+	var pkgs []descriptor.GoPackage
+	for _, p := range queryParamPopulators(svc, reg) {
+		pkgs = append(pkgs, p.goPkgs...)
+	}
+	return pkgs
+}
+
+// newQueryParamField returns how the populator of "msg" sets "f".
+// It returns false if the field should be left to the reflection-based implementation.
+func newQueryParamField(svc *descriptor.Service, msg *descriptor.Message, f *descriptor.Field, reg *descriptor.Registry) (queryParamField, bool) {
+	pkgPath := svc.File.GoPkg.Path
+	qf := queryParamField{
+		Names:    []string{f.GetName()},
+		GoName:   gogen.CamelCase(f.GetName()),
+		Repeated: f.GetLabel() == protodescriptor.FieldDescriptorProto_LABEL_REPEATED,
+	}
+	if f.OneofIndex != nil {
+		qf.Oneof = gogen.CamelCase(msg.GetOneofDecl()[f.GetOneofIndex()].GetName())
+		qf.OneofWrapper = msg.GoType(pkgPath) + "_" + qf.GoName
+	} else if jsonName := f.GetJsonName(); jsonName != "" && jsonName != f.GetName() {
+		// The reflection-based implementation does not accept json names for oneof members.
+		qf.Names = append(qf.Names, jsonName)
+	}
+
+	switch f.GetType() {
+	case protodescriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		if qf.Repeated || qf.Oneof != "" {
+			return queryParamField{}, false
+		}
+		fieldMsg, err := reg.LookupMsg("", f.GetTypeName())
+		if err != nil || !isQueryParamPopulatable(fieldMsg) {
+			return queryParamField{}, false
+		}
+		qf.MessageFunc = queryParamPopulatorName(svc, fieldMsg)
+		qf.MessageGoType = fieldMsg.GoType(pkgPath)
+	case protodescriptor.FieldDescriptorProto_TYPE_ENUM:
+		e, err := reg.LookupEnum("", f.GetTypeName())
+		if err != nil {
+			return queryParamField{}, false
+		}
+		qf.Enum = e.GoType(pkgPath)
+		qf.GoElemType = qf.Enum
+	default:
+		conv, ok := queryParamConvertFuncs[f.GetType()]
+		if !ok {
+			return queryParamField{}, false
+		}
+		qf.ConvertFunc = conv.fn
+		qf.GoElemType = conv.goType
+	}
+	return qf, true
+}
+
+var queryParamConvertFuncs = map[protodescriptor.FieldDescriptorProto_Type]struct {
+	fn     string
+	goType string
+}{
+	protodescriptor.FieldDescriptorProto_TYPE_DOUBLE:   {"runtime.Float64", "float64"},
+	protodescriptor.FieldDescriptorProto_TYPE_FLOAT:    {"runtime.Float32", "float32"},
+	protodescriptor.FieldDescriptorProto_TYPE_INT64:    {"runtime.Int64", "int64"},
+	protodescriptor.FieldDescriptorProto_TYPE_UINT64:   {"runtime.Uint64", "uint64"},
+	protodescriptor.FieldDescriptorProto_TYPE_INT32:    {"runtime.Int32", "int32"},
+	protodescriptor.FieldDescriptorProto_TYPE_FIXED64:  {"runtime.Uint64", "uint64"},
+	protodescriptor.FieldDescriptorProto_TYPE_FIXED32:  {"runtime.Uint32", "uint32"},
+	protodescriptor.FieldDescriptorProto_TYPE_BOOL:     {"runtime.Bool", "bool"},
+	protodescriptor.FieldDescriptorProto_TYPE_STRING:   {"runtime.String", "string"},
+	protodescriptor.FieldDescriptorProto_TYPE_BYTES:    {"runtime.Bytes", "[]byte"},
+	protodescriptor.FieldDescriptorProto_TYPE_UINT32:   {"runtime.Uint32", "uint32"},
+	protodescriptor.FieldDescriptorProto_TYPE_SFIXED32: {"runtime.Int32", "int32"},
+	protodescriptor.FieldDescriptorProto_TYPE_SFIXED64: {"runtime.Int64", "int64"},
+	protodescriptor.FieldDescriptorProto_TYPE_SINT32:   {"runtime.Int32", "int32"},
+	protodescriptor.FieldDescriptorProto_TYPE_SINT64:   {"runtime.Int64", "int64"},
+}
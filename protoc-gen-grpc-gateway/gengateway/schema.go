@@ -0,0 +1,379 @@
+package gengateway
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	protodescriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	gogen "github.com/golang/protobuf/protoc-gen-go/generator"
+	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
+	swagger_options "github.com/grpc-ecosystem/grpc-gateway/protoc-gen-swagger/options"
+)
+
+// schemaValidator describes a generated function which validates a message of a specific type
+// against the JSON schema constraints in its `openapiv2_field` and `openapiv2_schema` options.
+type schemaValidator struct {
+	// FuncName is the name of the generated function.
+	FuncName string
+	// GoType is the go type of the message which the function validates.
+	GoType string
+	// Fields is the list of the constrained fields of the message.
+	Fields []schemaField
+}
+
+// schemaField describes how a generated validator checks a field.
+type schemaField struct {
+	// Name is the name of the field in field violations.
+	Name string
+	// Getter is a go expression which gets the value of the field from "msg".
+	Getter string
+	// Repeated is true if the field is a repeated field.
+	// In that case the constraints on values are applied to each element.
+	Repeated bool
+	// Required is true if the field is listed in `required` of the message.
+	Required bool
+	// Present is a go expression which is true if the field has a non-zero value.
+	Present string
+	// OneofCase is a go type assertion which succeeds if the field is the member set to its oneof,
+	// or "" if the field is not a member of a oneof. The constraints on values apply only to the member set.
+	OneofCase string
+	// MinItems and MaxItems are the constraints on the number of elements of a repeated field. 0 means no constraint.
+	MinItems, MaxItems uint64
+	// MinLength and MaxLength are the constraints on the length of a string field. 0 means no constraint.
+	MinLength, MaxLength uint64
+	// PatternVar is the name of the generated variable which holds the compiled Pattern.
+	PatternVar string
+	// Pattern is the regular expression which a string field must match.
+	Pattern string
+	// Minimum and Maximum are go expressions of the constraints on a numeric field. "" means no constraint.
+	Minimum, Maximum string
+	// RangeType is the go type in which a numeric field is compared with Minimum and Maximum,
+	// i.e. "int64", "uint64" or "float64".
+	RangeType string
+	// ExclusiveMinimum and ExclusiveMaximum are true if the field must not be equal to Minimum and Maximum.
+	ExclusiveMinimum, ExclusiveMaximum bool
+	// MessageFunc is the name of the validator of the field if it is a constrained message field.
+	MessageFunc string
+}
+
+// Value returns a go expression of a value to which the constraints on values are applied.
+func (f schemaField) Value() string {
+	if f.Repeated {
+		return "e"
+	}
+	return f.Getter
+}
+
+// RangeSuffix returns the suffix of the methods of runtime.SchemaValidator which compare values in RangeType.
+func (f schemaField) RangeSuffix() string {
+	switch f.RangeType {
+	case "int64":
+		return "Int64"
+	case "uint64":
+		return "Uint64"
+	}
+	return ""
+}
+
+// HasValueConstraints returns true if the generated validator needs to look into the values of the field.
+func (f schemaField) HasValueConstraints() bool {
+	return f.MinLength > 0 || f.MaxLength > 0 || f.Pattern != "" || f.Minimum != "" || f.Maximum != "" || f.MessageFunc != ""
+}
+
+// schemaValidatorName returns the name of the validator of "msg" generated for "svc".
+func schemaValidatorName(svc *descriptor.Service, msg *descriptor.Message) string {
+	ident := strings.Replace(msg.GoType(svc.File.GoPkg.Path), ".", "_", -1)
+	return "validateSchema_" + svc.GetName() + "_" + ident
+}
+
+// isSchemaValidatable returns true if a validator can be generated for "msg" in "pkg".
+// The validators do not follow messages into other go packages, which the generated file may not import.
+func isSchemaValidatable(msg *descriptor.Message, pkg descriptor.GoPackage) bool {
+	if msg == nil || msg.File == nil || msg.File.GoPkg.Path != pkg.Path {
+		return false
+	}
+	if msg.GetOptions().GetMapEntry() {
+		return false
+	}
+	return msg.File.GetPackage() != "google.protobuf"
+}
+
+// isSchemaConstrained returns true if "msg" or a message reachable from "msg" through message fields has JSON schema constraints.
+func isSchemaConstrained(msg *descriptor.Message, reg *descriptor.Registry) bool {
+	if msg == nil || msg.File == nil || !isSchemaValidatable(msg, msg.File.GoPkg) {
+		return false
+	}
+	pkg := msg.File.GoPkg
+	seen := make(map[string]bool)
+	var visit func(msg *descriptor.Message) bool
+	visit = func(msg *descriptor.Message) bool {
+		if seen[msg.FQMN()] {
+			return false
+		}
+		seen[msg.FQMN()] = true
+		if len(schemaRequired(msg)) > 0 {
+			return true
+		}
+		for _, f := range msg.Fields {
+			if schemaFieldOptions(f) != nil {
+				return true
+			}
+			if f.GetType() != protodescriptor.FieldDescriptorProto_TYPE_MESSAGE {
+				continue
+			}
+			fieldMsg, err := reg.LookupMsg("", f.GetTypeName())
+			if err == nil && isSchemaValidatable(fieldMsg, pkg) && visit(fieldMsg) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(msg)
+}
+
+// schemaValidators returns the validators to be generated for "svc".
+// It covers the request messages of the methods which have constraints and,
+// transitively, the constrained messages of their message fields.
+func schemaValidators(svc *descriptor.Service, reg *descriptor.Registry) ([]*schemaValidator, error) {
+	if reg == nil || !reg.IsGenerateSchemaValidation() {
+		return nil, nil
+	}
+	var (
+		queue []*descriptor.Message
+		seen  = make(map[string]bool)
+	)
+	enqueue := func(msg *descriptor.Message) {
+		if !seen[msg.FQMN()] {
+			seen[msg.FQMN()] = true
+			queue = append(queue, msg)
+		}
+	}
+	for _, meth := range svc.Methods {
+		if len(meth.Bindings) > 0 && isSchemaConstrained(meth.RequestType, reg) {
+			enqueue(meth.RequestType)
+		}
+	}
+
+	var validators []*schemaValidator
+	for len(queue) > 0 {
+		msg := queue[0]
+		queue = queue[1:]
+		v := &schemaValidator{
+			FuncName: schemaValidatorName(svc, msg),
+			GoType:   msg.GoType(svc.File.GoPkg.Path),
+		}
+		required := make(map[string]bool)
+		for _, name := range schemaRequired(msg) {
+			required[name] = true
+		}
+		for _, f := range msg.Fields {
+			sf, err := newSchemaField(svc, msg, f, required[f.GetName()] || required[f.GetJsonName()], reg)
+			if err != nil {
+				return nil, err
+			}
+			if sf.MessageFunc != "" {
+				fieldMsg, _ := reg.LookupMsg("", f.GetTypeName())
+				enqueue(fieldMsg)
+			}
+			if sf.Required || sf.MinItems > 0 || sf.MaxItems > 0 || sf.HasValueConstraints() {
+				v.Fields = append(v.Fields, sf)
+			}
+		}
+		validators = append(validators, v)
+	}
+	return validators, nil
+}
+
+// newSchemaField returns how the validator of "msg" checks "f".
+func newSchemaField(svc *descriptor.Service, msg *descriptor.Message, f *descriptor.Field, required bool, reg *descriptor.Registry) (schemaField, error) {
+	sf := schemaField{
+		Name:     f.GetName(),
+		Getter:   "msg.Get" + gogen.CamelCase(f.GetName()) + "()",
+		Repeated: f.GetLabel() == protodescriptor.FieldDescriptorProto_LABEL_REPEATED,
+		Required: required,
+	}
+	switch {
+	case sf.Repeated || f.GetType() == protodescriptor.FieldDescriptorProto_TYPE_BYTES:
+		sf.Present = "len(" + sf.Getter + ") != 0"
+	case f.GetType() == protodescriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		sf.Present = sf.Getter + " != nil"
+	case f.GetType() == protodescriptor.FieldDescriptorProto_TYPE_BOOL:
+		sf.Present = sf.Getter
+	case f.GetType() == protodescriptor.FieldDescriptorProto_TYPE_STRING:
+		sf.Present = sf.Getter + ` != ""`
+	default:
+		sf.Present = sf.Getter + " != 0"
+	}
+	if f.OneofIndex != nil {
+		oneof := gogen.CamelCase(msg.GetOneofDecl()[f.GetOneofIndex()].GetName())
+		sf.OneofCase = fmt.Sprintf("msg.%s.(*%s_%s)", oneof, msg.GoType(svc.File.GoPkg.Path), gogen.CamelCase(f.GetName()))
+	}
+
+	if f.GetType() == protodescriptor.FieldDescriptorProto_TYPE_MESSAGE {
+		fieldMsg, err := reg.LookupMsg("", f.GetTypeName())
+		if err == nil && isSchemaValidatable(fieldMsg, msg.File.GoPkg) && isSchemaConstrained(fieldMsg, reg) {
+			sf.MessageFunc = schemaValidatorName(svc, fieldMsg)
+		}
+	}
+
+	opts := schemaFieldOptions(f)
+	if opts == nil {
+		return sf, nil
+	}
+	if sf.Repeated {
+		sf.MinItems, sf.MaxItems = opts.GetMinItems(), opts.GetMaxItems()
+	} else if opts.GetMinItems() > 0 || opts.GetMaxItems() > 0 {
+		glog.Warningf("%s.%s: min_items and max_items are ignored for a singular field", msg.FQMN(), f.GetName())
+	}
+
+	hasLength := opts.GetMinLength() > 0 || opts.GetMaxLength() > 0 || opts.GetPattern() != ""
+	// A zero bound is indistinguishable from an absent one unless it is exclusive.
+	hasMinimum := opts.GetMinimum() != 0 || opts.GetExclusiveMinimum()
+	hasMaximum := opts.GetMaximum() != 0 || opts.GetExclusiveMaximum()
+	hasRange := hasMinimum || hasMaximum
+	switch {
+	case f.GetType() == protodescriptor.FieldDescriptorProto_TYPE_STRING:
+		sf.MinLength, sf.MaxLength = opts.GetMinLength(), opts.GetMaxLength()
+		if pattern := opts.GetPattern(); pattern != "" {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return schemaField{}, fmt.Errorf("invalid pattern of %s.%s: %v", msg.FQMN(), f.GetName(), err)
+			}
+			sf.Pattern = pattern
+			sf.PatternVar = "schemaPattern_" + strings.TrimPrefix(schemaValidatorName(svc, msg), "validateSchema_") + "_" + gogen.CamelCase(f.GetName())
+		}
+		if hasRange {
+			glog.Warningf("%s.%s: minimum and maximum are ignored for a string field", msg.FQMN(), f.GetName())
+		}
+	case isSchemaNumeric(f.GetType()):
+		sf.RangeType = schemaRangeType(f.GetType())
+		var err error
+		if hasMinimum {
+			sf.Minimum, sf.ExclusiveMinimum, err = schemaBound(sf.RangeType, opts.GetMinimum(), opts.GetExclusiveMinimum(), true)
+			if err != nil {
+				return schemaField{}, fmt.Errorf("invalid minimum of %s.%s: %v", msg.FQMN(), f.GetName(), err)
+			}
+		}
+		if hasMaximum {
+			sf.Maximum, sf.ExclusiveMaximum, err = schemaBound(sf.RangeType, opts.GetMaximum(), opts.GetExclusiveMaximum(), false)
+			if err != nil {
+				return schemaField{}, fmt.Errorf("invalid maximum of %s.%s: %v", msg.FQMN(), f.GetName(), err)
+			}
+		}
+		if hasMaximum && !hasMinimum {
+			glog.Warningf("%s.%s: minimum: 0 is not checked because it cannot be told from an absent minimum; use exclusive_minimum to check a zero minimum", msg.FQMN(), f.GetName())
+		}
+		if hasMinimum && !hasMaximum {
+			glog.Warningf("%s.%s: maximum: 0 is not checked because it cannot be told from an absent maximum; use exclusive_maximum to check a zero maximum", msg.FQMN(), f.GetName())
+		}
+		if hasLength {
+			glog.Warningf("%s.%s: min_length, max_length and pattern are ignored for a numeric field", msg.FQMN(), f.GetName())
+		}
+	default:
+		if hasLength || hasRange {
+			glog.Warningf("%s.%s: constraints on values are ignored for a field of %s", msg.FQMN(), f.GetName(), f.GetType())
+		}
+	}
+	return sf, nil
+}
+
+// schemaRangeType returns the go type in which a numeric field of type "t" is compared with its minimum and maximum.
+func schemaRangeType(t protodescriptor.FieldDescriptorProto_Type) string {
+	switch t {
+	case protodescriptor.FieldDescriptorProto_TYPE_INT64,
+		protodescriptor.FieldDescriptorProto_TYPE_INT32,
+		protodescriptor.FieldDescriptorProto_TYPE_SFIXED32,
+		protodescriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		protodescriptor.FieldDescriptorProto_TYPE_SINT32,
+		protodescriptor.FieldDescriptorProto_TYPE_SINT64:
+		return "int64"
+	case protodescriptor.FieldDescriptorProto_TYPE_UINT64,
+		protodescriptor.FieldDescriptorProto_TYPE_FIXED64,
+		protodescriptor.FieldDescriptorProto_TYPE_FIXED32,
+		protodescriptor.FieldDescriptorProto_TYPE_UINT32:
+		return "uint64"
+	}
+	return "float64"
+}
+
+// schemaBound returns a go expression of the minimum ("isMin") or maximum "bound" in "rangeType" and whether it is exclusive.
+// Fractional bounds of integers are rounded to the nearest integers within the range.
+// It returns "" if every value of "rangeType" satisfies the bound.
+func schemaBound(rangeType string, bound float64, exclusive, isMin bool) (string, bool, error) {
+	if rangeType == "float64" {
+		return strconv.FormatFloat(bound, 'g', -1, 64), exclusive, nil
+	}
+	if b := math.Ceil(bound); isMin && b != bound {
+		bound, exclusive = b, false
+	} else if b := math.Floor(bound); !isMin && b != bound {
+		bound, exclusive = b, false
+	}
+
+	lower, upper := -math.Exp2(63), math.Exp2(63)
+	if rangeType == "uint64" {
+		lower, upper = 0, math.Exp2(64)
+	}
+	switch {
+	case isMin && (bound < lower || bound == lower && !exclusive):
+		return "", false, nil
+	case !isMin && bound >= upper:
+		return "", false, nil
+	case bound < lower || bound >= upper:
+		return "", false, fmt.Errorf("%v is out of the range of %s", bound, rangeType)
+	}
+	if rangeType == "uint64" {
+		return strconv.FormatUint(uint64(bound), 10), exclusive, nil
+	}
+	return strconv.FormatInt(int64(bound), 10), exclusive, nil
+}
+
+// schemaFieldOptions returns the `openapiv2_field` option of "f", or nil if it does not have one.
+func schemaFieldOptions(f *descriptor.Field) *swagger_options.JSONSchema {
+	if f.Options == nil || !proto.HasExtension(f.Options, swagger_options.E_Openapiv2Field) {
+		return nil
+	}
+	ext, err := proto.GetExtension(f.Options, swagger_options.E_Openapiv2Field)
+	if err != nil {
+		glog.Warningf("Failed to read openapiv2_field option of %s.%s: %v", f.Message.FQMN(), f.GetName(), err)
+		return nil
+	}
+	opts, _ := ext.(*swagger_options.JSONSchema)
+	return opts
+}
+
+// schemaRequired returns the names of the fields listed in `required` of the `openapiv2_schema` option of "msg".
+func schemaRequired(msg *descriptor.Message) []string {
+	if msg.Options == nil || !proto.HasExtension(msg.Options, swagger_options.E_Openapiv2Schema) {
+		return nil
+	}
+	ext, err := proto.GetExtension(msg.Options, swagger_options.E_Openapiv2Schema)
+	if err != nil {
+		glog.Warningf("Failed to read openapiv2_schema option of %s: %v", msg.FQMN(), err)
+		return nil
+	}
+	opts, _ := ext.(*swagger_options.Schema)
+	return opts.GetJsonSchema().GetRequired()
+}
+
+func isSchemaNumeric(t protodescriptor.FieldDescriptorProto_Type) bool {
+	switch t {
+	case protodescriptor.FieldDescriptorProto_TYPE_DOUBLE,
+		protodescriptor.FieldDescriptorProto_TYPE_FLOAT,
+		protodescriptor.FieldDescriptorProto_TYPE_INT64,
+		protodescriptor.FieldDescriptorProto_TYPE_UINT64,
+		protodescriptor.FieldDescriptorProto_TYPE_INT32,
+		protodescriptor.FieldDescriptorProto_TYPE_FIXED64,
+		protodescriptor.FieldDescriptorProto_TYPE_FIXED32,
+		protodescriptor.FieldDescriptorProto_TYPE_UINT32,
+		protodescriptor.FieldDescriptorProto_TYPE_SFIXED32,
+		protodescriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		protodescriptor.FieldDescriptorProto_TYPE_SINT32,
+		protodescriptor.FieldDescriptorProto_TYPE_SINT64:
+		return true
+	}
+	return false
+}
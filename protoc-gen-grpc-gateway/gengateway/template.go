@@ -7,6 +7,7 @@ import (
 	"text/template"
 
 	"github.com/golang/glog"
+	protodescriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
 	"github.com/grpc-ecosystem/grpc-gateway/utilities"
 )
@@ -55,6 +56,38 @@ func (b binding) QueryParamFilter() queryParamFilter {
 	return queryParamFilter{utilities.NewDoubleArray(seqs)}
 }
 
+// QueryParamPopulator returns the name of the generated function which populates query parameters
+// into the request message, or an empty string if the binding relies on runtime.PopulateFieldValuesFromPath.
+func (b binding) QueryParamPopulator() string {
+	if !isQueryParamPopulatable(b.Method.RequestType) {
+		return ""
+	}
+	return queryParamPopulatorName(b.Method.Service, b.Method.RequestType)
+}
+
+// SchemaValidator returns the name of the generated function which validates the request message
+// against its JSON schema constraints, or an empty string if the request message is not validated.
+func (b binding) SchemaValidator() string {
+	if b.Registry == nil || !b.Registry.IsGenerateSchemaValidation() || !isSchemaConstrained(b.Method.RequestType, b.Registry) {
+		return ""
+	}
+	return schemaValidatorName(b.Method.Service, b.Method.RequestType)
+}
+
+// IsHTTPBodyBody returns true if the request body of the binding is mapped to google.api.HttpBody,
+// which is decoded by runtime.DecodeHTTPBody instead of the marshaler.
+func (b binding) IsHTTPBodyBody() bool {
+	if b.Body == nil {
+		return false
+	}
+	if len(b.Body.FieldPath) == 0 {
+		return b.Method.RequestType.FQMN() == ".google.api.HttpBody"
+	}
+	target := b.Body.FieldPath[len(b.Body.FieldPath)-1].Target
+	return target.GetTypeName() == ".google.api.HttpBody" &&
+		target.GetLabel() != protodescriptor.FieldDescriptorProto_LABEL_REPEATED
+}
+
 // HasEnumPathParam returns true if the path parameter slice contains a parameter
 // that maps to an enum proto field that is not repeated, if not false is returned.
 func (b binding) HasEnumPathParam() bool {
@@ -130,6 +163,16 @@ func applyTemplate(p param, reg *descriptor.Registry) (string, error) {
 			}
 		}
 		if methodWithBindingsSeen {
+			if err := queryParamPopulatorTemplate.Execute(w, queryParamPopulators(svc, reg)); err != nil {
+				return "", err
+			}
+			validators, err := schemaValidators(svc, reg)
+			if err != nil {
+				return "", err
+			}
+			if err := schemaValidatorTemplate.Execute(w, validators); err != nil {
+				return "", err
+			}
 			targetServices = append(targetServices, svc)
 		}
 	}
@@ -194,7 +237,7 @@ func request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ctx cont
 	var metadata runtime.ServerMetadata
 	stream, err := client.{{.Method.GetName}}(ctx)
 	if err != nil {
-		grpclog.Infof("Failed to start streaming: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to start streaming: %v", err)
 		return nil, metadata, err
 	}
 	dec := marshaler.NewDecoder(req.Body)
@@ -205,22 +248,33 @@ func request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ctx cont
 			break
 		}
 		if err != nil {
-			grpclog.Infof("Failed to decode request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to decode request: %v", err)
 			return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 		}
+{{- if .SchemaValidator}}
+		var schema runtime.SchemaValidator
+		{{.SchemaValidator}}(&schema, "", &protoReq)
+		if err = schema.Err(); err != nil {
+			return nil, metadata, err
+		}
+{{- end}}
+		if err = runtime.ValidateRequest(ctx, &protoReq); err != nil {
+			return nil, metadata, err
+		}
 		if err = stream.Send(&protoReq); err != nil {
-			grpclog.Infof("Failed to send request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to send request: %v", err)
 			return nil, metadata, err
 		}
+		runtime.StreamMessageSent(ctx)
 	}
 
 	if err := stream.CloseSend(); err != nil {
-		grpclog.Infof("Failed to terminate client stream: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", err)
 		return nil, metadata, err
 	}
 	header, err := stream.Header()
 	if err != nil {
-		grpclog.Infof("Failed to get header from client: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to get header from client: %v", err)
 		return nil, metadata, err
 	}
 	metadata.HeaderMD = header
@@ -244,9 +298,15 @@ var (
 	var protoReq {{.Method.RequestType.GoType .Method.Service.File.GoPkg.Path}}
 	var metadata runtime.ServerMetadata
 {{if .Body}}
+{{- if .IsHTTPBodyBody}}
+	if err := runtime.DecodeHTTPBody(req, &{{.Body.AssignableExpr "protoReq"}}); err != nil {
+		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+{{- else}}
 	if err := marshaler.NewDecoder(req.Body).Decode(&{{.Body.AssignableExpr "protoReq"}}); err != nil && err != io.EOF  {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
+{{- end}}
 {{end}}
 {{if .PathParams}}
 	var (
@@ -290,10 +350,30 @@ var (
 	{{end}}
 {{end}}
 {{if .HasQueryParam}}
-	if err := runtime.PopulateQueryParameters(&protoReq, req.URL.Query(), filter_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}); err != nil {
+{{if .QueryParamPopulator}}
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}, func(fieldPath, values []string) error {
+		return {{.QueryParamPopulator}}(ctx, &protoReq, &protoReq, fieldPath, 0, values)
+	}); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
+{{else}}
+	if err := runtime.ForEachQueryParameter(req.URL.Query(), filter_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}, func(fieldPath, values []string) error {
+		return runtime.PopulateFieldValuesFromPath(ctx, &protoReq, fieldPath, values)
+	}); err != nil {
+		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+{{end}}
 {{end}}
+{{- if .SchemaValidator}}
+	var schema runtime.SchemaValidator
+	{{.SchemaValidator}}(&schema, "", &protoReq)
+	if err := schema.Err(); err != nil {
+		return nil, metadata, err
+	}
+{{- end}}
+	if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
 {{if .Method.GetServerStreaming}}
 	stream, err := client.{{.Method.GetName}}(ctx, &protoReq)
 	if err != nil {
@@ -316,7 +396,7 @@ var (
 	var metadata runtime.ServerMetadata
 	stream, err := client.{{.Method.GetName}}(ctx)
 	if err != nil {
-		grpclog.Infof("Failed to start streaming: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to start streaming: %v", err)
 		return nil, metadata, err
 	}
 	dec := marshaler.NewDecoder(req.Body)
@@ -327,18 +407,29 @@ var (
 			return err
 		}
 		if err != nil {
-			grpclog.Infof("Failed to decode request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to decode request: %v", err)
+			return err
+		}
+{{- if .SchemaValidator}}
+		var schema runtime.SchemaValidator
+		{{.SchemaValidator}}(&schema, "", &protoReq)
+		if err := schema.Err(); err != nil {
+			return err
+		}
+{{- end}}
+		if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {
 			return err
 		}
 		if err := stream.Send(&protoReq); err != nil {
-			grpclog.Infof("Failed to send request: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to send request: %v", err)
 			return err
 		}
+		runtime.StreamMessageSent(ctx)
 		return nil
 	}
 	if err := handleSend(); err != nil {
 		if cerr := stream.CloseSend(); cerr != nil {
-			grpclog.Infof("Failed to terminate client stream: %v", cerr)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", cerr)
 		}
 		if err == io.EOF {
 			return stream, metadata, nil
@@ -352,17 +443,138 @@ var (
 			}
 		}
 		if err := stream.CloseSend(); err != nil {
-			grpclog.Infof("Failed to terminate client stream: %v", err)
+			runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to terminate client stream: %v", err)
 		}
 	}()
 	header, err := stream.Header()
 	if err != nil {
-		grpclog.Infof("Failed to get header from client: %v", err)
+		runtime.LoggerFromContext(ctx).Infof(ctx, "Failed to get header from client: %v", err)
 		return nil, metadata, err
 	}
 	metadata.HeaderMD = header
 	return stream, metadata, nil
 }
+`))
+
+	queryParamPopulatorTemplate = template.Must(template.New("query-param-populator").Parse(`
+{{range $p := .}}
+func {{$p.FuncName}}(ctx context.Context, root proto.Message, msg *{{$p.GoType}}, fieldPath []string, i int, values []string) error {
+{{- if $p.Fields}}
+	switch fieldPath[i] {
+	{{- range $f := $p.Fields}}
+	case {{range $j, $name := $f.Names}}{{if $j}}, {{end}}{{$name | printf "%q"}}{{end}}:
+	{{- if $f.MessageFunc}}
+		if i == len(fieldPath)-1 {
+			break
+		}
+		if msg.{{$f.GoName}} == nil {
+			msg.{{$f.GoName}} = &{{$f.MessageGoType}}{}
+		}
+		return {{$f.MessageFunc}}(ctx, root, msg.{{$f.GoName}}, fieldPath, i+1, values)
+	{{- else if $f.Repeated}}
+		if i != len(fieldPath)-1 {
+			break
+		}
+		s := make([]{{$f.GoElemType}}, len(values))
+		for j, value := range values {
+		{{- if $f.Enum}}
+			e, ok := {{$f.Enum}}_value[value]
+			if !ok {
+				return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+			}
+			s[j] = {{$f.Enum}}(e)
+		{{- else}}
+			v, err := {{$f.ConvertFunc}}(value)
+			if err != nil {
+				return err
+			}
+			s[j] = v
+		{{- end}}
+		}
+		msg.{{$f.GoName}} = s
+		return nil
+	{{- else}}
+		if i != len(fieldPath)-1 || len(values) != 1{{if $f.Oneof}} || msg.{{$f.Oneof}} != nil{{end}} {
+			break
+		}
+	{{- if $f.Enum}}
+		e, ok := {{$f.Enum}}_value[values[0]]
+		if !ok {
+			break
+		}
+		v := {{$f.Enum}}(e)
+	{{- else}}
+		v, err := {{$f.ConvertFunc}}(values[0])
+		if err != nil {
+			return err
+		}
+	{{- end}}
+	{{- if $f.Oneof}}
+		msg.{{$f.Oneof}} = &{{$f.OneofWrapper}}{ {{- $f.GoName}}: v}
+	{{- else}}
+		msg.{{$f.GoName}} = v
+	{{- end}}
+		return nil
+	{{- end}}
+	{{- end}}
+	}
+{{- end}}
+	return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)
+}
+{{end}}
+`))
+
+	schemaValidatorTemplate = template.Must(template.New("schema-validator").Parse(`
+{{range $v := .}}
+{{- range $f := $v.Fields}}
+{{- if $f.Pattern}}
+var {{$f.PatternVar}} = runtime.MustCompileSchemaPattern({{$f.Pattern | printf "%q"}})
+{{- end}}
+{{- end}}
+
+func {{$v.FuncName}}(v *runtime.SchemaValidator, path string, msg *{{$v.GoType}}) {
+	if msg == nil {
+		return
+	}
+{{- range $f := $v.Fields}}
+{{- if $f.Required}}
+	v.Required(path+{{$f.Name | printf "%q"}}, {{$f.Present}})
+{{- end}}
+{{- if or $f.MinItems $f.MaxItems}}
+	v.Items(path+{{$f.Name | printf "%q"}}, len({{$f.Getter}}), {{$f.MinItems}}, {{$f.MaxItems}})
+{{- end}}
+{{- if $f.HasValueConstraints}}
+{{- if $f.OneofCase}}
+	if _, ok := {{$f.OneofCase}}; ok {
+{{- end}}
+{{- if $f.Repeated}}
+	for _, e := range {{$f.Getter}} {
+{{- end}}
+{{- if or $f.MinLength $f.MaxLength}}
+	v.Length(path+{{$f.Name | printf "%q"}}, {{$f.Value}}, {{$f.MinLength}}, {{$f.MaxLength}})
+{{- end}}
+{{- if $f.Pattern}}
+	v.Pattern(path+{{$f.Name | printf "%q"}}, {{$f.Value}}, {{$f.PatternVar}})
+{{- end}}
+{{- if $f.Minimum}}
+	v.Minimum{{$f.RangeSuffix}}(path+{{$f.Name | printf "%q"}}, {{$f.RangeType}}({{$f.Value}}), {{$f.Minimum}}, {{$f.ExclusiveMinimum}})
+{{- end}}
+{{- if $f.Maximum}}
+	v.Maximum{{$f.RangeSuffix}}(path+{{$f.Name | printf "%q"}}, {{$f.RangeType}}({{$f.Value}}), {{$f.Maximum}}, {{$f.ExclusiveMaximum}})
+{{- end}}
+{{- if $f.MessageFunc}}
+	{{$f.MessageFunc}}(v, path+{{printf "%s." $f.Name | printf "%q"}}, {{$f.Value}})
+{{- end}}
+{{- if $f.Repeated}}
+	}
+{{- end}}
+{{- if $f.OneofCase}}
+	}
+{{- end}}
+{{- end}}
+{{- end}}
+}
+{{end}}
 `))
 
 	trailerTemplate = template.Must(template.New("trailer").Parse(`
@@ -378,14 +590,14 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint(ctx context.Co
 	defer func() {
 		if err != nil {
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 			return
 		}
 		go func() {
 			<-ctx.Done()
 			if cerr := conn.Close(); cerr != nil {
-				grpclog.Infof("Failed to close conn to %s: %v", endpoint, cerr)
+				mux.Logger().Infof(ctx, "Failed to close conn to %s: %v", endpoint, cerr)
 			}
 		}()
 	}()
@@ -414,6 +626,7 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx context.Context,
 		ctx, cancel := context.WithCancel(ctx)
 	{{- end }}
 		defer cancel()
+		ctx = runtime.NewRPCMethodContext(ctx, "/{{if $svc.File.Package}}{{$svc.File.GetPackage}}.{{end}}{{$svc.GetName}}/{{$m.GetName}}")
 		if cn, ok := w.(http.CloseNotifier); ok {
 			go func(done <-chan struct{}, closed <-chan bool) {
 				select {
@@ -1,13 +1,17 @@
 package gengateway
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
 	protodescriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
 	"github.com/grpc-ecosystem/grpc-gateway/protoc-gen-grpc-gateway/httprule"
+	_ "github.com/grpc-ecosystem/grpc-gateway/protoc-gen-swagger/options"
+	"google.golang.org/genproto/googleapis/api/annotations"
 )
 
 func crossLinkFixture(f *descriptor.File) *descriptor.File {
@@ -85,6 +89,9 @@ func TestApplyTemplateHeader(t *testing.T) {
 	if want := "package example_pb\n"; !strings.Contains(got, want) {
 		t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
 	}
+	if want := `ctx = runtime.NewRPCMethodContext(ctx, "/example.ExampleService/Example")`; !strings.Contains(got, want) {
+		t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
+	}
 }
 
 func TestApplyTemplateRequestWithoutClientStreaming(t *testing.T) {
@@ -239,6 +246,9 @@ func TestApplyTemplateRequestWithoutClientStreaming(t *testing.T) {
 		if want := `protoReq.GetNested().Int32, err = runtime.Int32P(val)`; !strings.Contains(got, want) {
 			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
 		}
+		if want := `if err := runtime.ValidateRequest(ctx, &protoReq); err != nil {`; !strings.Contains(got, want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
+		}
 		if want := `func RegisterExampleServiceHandler(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {`; !strings.Contains(got, want) {
 			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
 		}
@@ -402,3 +412,347 @@ func TestApplyTemplateRequestWithClientStreaming(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyTemplateQueryParamPopulator(t *testing.T) {
+	const src = `
+		file_to_generate: "example.proto"
+		proto_file <
+			name: "example.proto"
+			package: "example"
+			syntax: "proto3"
+			options < go_package: "example.com/path/to/example;example_pb" >
+			message_type <
+				name: "ExampleMessage"
+				field < name: "str" json_name: "str" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING >
+				field < name: "int_value" json_name: "intValue" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 >
+				field < name: "nested" json_name: "nested" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".example.NestedMessage" >
+				field < name: "enum_value" json_name: "enumValue" number: 4 label: LABEL_REPEATED type: TYPE_ENUM type_name: ".example.ExampleEnum" >
+				field < name: "oneof_str" json_name: "oneofStr" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 >
+				field < name: "map_value" json_name: "mapValue" number: 6 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".example.ExampleMessage.MapValueEntry" >
+				nested_type <
+					name: "MapValueEntry"
+					field < name: "key" json_name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING >
+					field < name: "value" json_name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING >
+					options < map_entry: true >
+				>
+				oneof_decl < name: "choice" >
+			>
+			message_type <
+				name: "NestedMessage"
+				field < name: "flag" json_name: "flag" number: 1 label: LABEL_OPTIONAL type: TYPE_BOOL >
+			>
+			enum_type <
+				name: "ExampleEnum"
+				value < name: "ZERO" number: 0 >
+			>
+			service <
+				name: "ExampleService"
+				method < name: "Echo" input_type: ".example.ExampleMessage" output_type: ".example.ExampleMessage" >
+			>
+		>
+	`
+	var req plugin.CodeGeneratorRequest
+	if err := proto.UnmarshalText(src, &req); err != nil {
+		t.Fatalf("proto.UnmarshalText(%s, &req) failed with %v; want success", src, err)
+	}
+	reg := descriptor.NewRegistry()
+	reg.AddExternalHTTPRule(".example.ExampleService.Echo", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/example/{str}"},
+	})
+	if err := reg.Load(&req); err != nil {
+		t.Fatalf("reg.Load(%s) failed with %v; want success", src, err)
+	}
+	file, err := reg.LookupFile("example.proto")
+	if err != nil {
+		t.Fatalf("reg.LookupFile(%q) failed with %v; want success", "example.proto", err)
+	}
+
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, reg)
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	for _, want := range []string{
+		`return populateQueryParameters_ExampleService_ExampleMessage(ctx, &protoReq, &protoReq, fieldPath, 0, values)`,
+		`func populateQueryParameters_ExampleService_ExampleMessage(ctx context.Context, root proto.Message, msg *ExampleMessage, fieldPath []string, i int, values []string) error {`,
+		`case "int_value", "intValue":`,
+		`v, err := runtime.Int32(values[0])`,
+		`return populateQueryParameters_ExampleService_NestedMessage(ctx, root, msg.Nested, fieldPath, i+1, values)`,
+		`e, ok := ExampleEnum_value[value]`,
+		`case "oneof_str":`,
+		`msg.Choice = &ExampleMessage_OneofStr{OneofStr: v}`,
+		`func populateQueryParameters_ExampleService_NestedMessage(ctx context.Context, root proto.Message, msg *NestedMessage, fieldPath []string, i int, values []string) error {`,
+		`return runtime.PopulateFieldValuesFromPath(ctx, root, fieldPath, values)`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
+		}
+	}
+	for _, notwanted := range []string{
+		`runtime.PopulateQueryParameters(`,
+		`"oneofStr"`,
+		`"map_value"`,
+		`populateQueryParameters_ExampleService_ExampleMessage_MapValueEntry`,
+	} {
+		if strings.Contains(got, notwanted) {
+			t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notwanted)
+		}
+	}
+}
+
+func TestApplyTemplateSchemaValidator(t *testing.T) {
+	const src = `
+		file_to_generate: "example.proto"
+		proto_file <
+			name: "example.proto"
+			package: "example"
+			syntax: "proto3"
+			options < go_package: "example.com/path/to/example;example_pb" >
+			message_type <
+				name: "ExampleMessage"
+				field <
+					name: "name" json_name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING
+					options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < min_length: 1 max_length: 10 pattern: "^[a-z]+$" > >
+				>
+				field <
+					name: "count" json_name: "count" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32
+					options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < minimum: 1 maximum: 100 exclusive_maximum: true > >
+				>
+				field <
+					name: "tags" json_name: "tags" number: 3 label: LABEL_REPEATED type: TYPE_STRING
+					options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < max_items: 5 max_length: 8 > >
+				>
+				field < name: "nested" json_name: "nested" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".example.NestedMessage" >
+				field < name: "plain" json_name: "plain" number: 5 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".example.PlainMessage" >
+				field <
+					name: "size" json_name: "size" number: 6 label: LABEL_OPTIONAL type: TYPE_UINT64
+					options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < minimum: 1.5 > >
+				>
+				field <
+					name: "ratio" json_name: "ratio" number: 7 label: LABEL_OPTIONAL type: TYPE_DOUBLE
+					options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < maximum: 0.5 > >
+				>
+				field <
+					name: "label" json_name: "label" number: 8 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0
+					options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < min_length: 2 > >
+				>
+				field <
+					name: "positive" json_name: "positive" number: 9 label: LABEL_OPTIONAL type: TYPE_INT32
+					options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < minimum: 0 exclusive_minimum: true > >
+				>
+				oneof_decl < name: "choice" >
+				options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_schema] < json_schema < required: "name" > > >
+			>
+			message_type <
+				name: "NestedMessage"
+				field < name: "id" json_name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING >
+				options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_schema] < json_schema < required: "id" > > >
+			>
+			message_type <
+				name: "PlainMessage"
+				field < name: "flag" json_name: "flag" number: 1 label: LABEL_OPTIONAL type: TYPE_BOOL >
+			>
+			service <
+				name: "ExampleService"
+				method < name: "Echo" input_type: ".example.ExampleMessage" output_type: ".example.ExampleMessage" >
+				method < name: "Plain" input_type: ".example.PlainMessage" output_type: ".example.PlainMessage" >
+			>
+		>
+	`
+	for _, spec := range []struct {
+		generate  bool
+		wants     []string
+		notwanted []string
+	}{
+		{
+			generate: true,
+			wants: []string{
+				`validateSchema_ExampleService_ExampleMessage(&schema, "", &protoReq)`,
+				`var schemaPattern_ExampleService_ExampleMessage_Name = runtime.MustCompileSchemaPattern("^[a-z]+$")`,
+				`func validateSchema_ExampleService_ExampleMessage(v *runtime.SchemaValidator, path string, msg *ExampleMessage) {`,
+				`v.Required(path+"name", msg.GetName() != "")`,
+				`v.Length(path+"name", msg.GetName(), 1, 10)`,
+				`v.Pattern(path+"name", msg.GetName(), schemaPattern_ExampleService_ExampleMessage_Name)`,
+				`v.MinimumInt64(path+"count", int64(msg.GetCount()), 1, false)`,
+				`v.MaximumInt64(path+"count", int64(msg.GetCount()), 100, true)`,
+				`v.MinimumUint64(path+"size", uint64(msg.GetSize()), 2, false)`,
+				`v.Maximum(path+"ratio", float64(msg.GetRatio()), 0.5, false)`,
+				`if _, ok := msg.Choice.(*ExampleMessage_Label); ok {`,
+				`v.Length(path+"label", msg.GetLabel(), 2, 0)`,
+				`v.MinimumInt64(path+"positive", int64(msg.GetPositive()), 0, true)`,
+				`v.Items(path+"tags", len(msg.GetTags()), 0, 5)`,
+				`v.Length(path+"tags", e, 0, 8)`,
+				`validateSchema_ExampleService_NestedMessage(v, path+"nested.", msg.GetNested())`,
+				`func validateSchema_ExampleService_NestedMessage(v *runtime.SchemaValidator, path string, msg *NestedMessage) {`,
+				`v.Required(path+"id", msg.GetId() != "")`,
+			},
+			notwanted: []string{
+				`validateSchema_ExampleService_PlainMessage`,
+			},
+		},
+		{
+			generate: false,
+			notwanted: []string{
+				`runtime.SchemaValidator`,
+				`validateSchema_`,
+			},
+		},
+	} {
+		var req plugin.CodeGeneratorRequest
+		if err := proto.UnmarshalText(src, &req); err != nil {
+			t.Fatalf("proto.UnmarshalText(%s, &req) failed with %v; want success", src, err)
+		}
+		reg := descriptor.NewRegistry()
+		reg.SetGenerateSchemaValidation(spec.generate)
+		reg.AddExternalHTTPRule(".example.ExampleService.Echo", &annotations.HttpRule{
+			Pattern: &annotations.HttpRule_Post{Post: "/v1/example"},
+			Body:    "*",
+		})
+		reg.AddExternalHTTPRule(".example.ExampleService.Plain", &annotations.HttpRule{
+			Pattern: &annotations.HttpRule_Post{Post: "/v1/plain"},
+			Body:    "*",
+		})
+		if err := reg.Load(&req); err != nil {
+			t.Fatalf("reg.Load(%s) failed with %v; want success", src, err)
+		}
+		file, err := reg.LookupFile("example.proto")
+		if err != nil {
+			t.Fatalf("reg.LookupFile(%q) failed with %v; want success", "example.proto", err)
+		}
+
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, reg)
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		for _, want := range spec.wants {
+			if !strings.Contains(got, want) {
+				t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
+			}
+		}
+		for _, notwanted := range spec.notwanted {
+			if strings.Contains(got, notwanted) {
+				t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notwanted)
+			}
+		}
+	}
+}
+
+func TestApplyTemplateSchemaValidatorInvalidOptions(t *testing.T) {
+	for _, spec := range []struct {
+		fieldType string
+		options   string
+	}{
+		{fieldType: "TYPE_STRING", options: `pattern: "("`},
+		{fieldType: "TYPE_UINT64", options: `maximum: -1`},
+		{fieldType: "TYPE_INT32", options: `minimum: 1e19`},
+	} {
+		src := fmt.Sprintf(`
+			file_to_generate: "example.proto"
+			proto_file <
+				name: "example.proto"
+				package: "example"
+				syntax: "proto3"
+				message_type <
+					name: "ExampleMessage"
+					field <
+						name: "value" json_name: "value" number: 1 label: LABEL_OPTIONAL type: %s
+						options < [grpc.gateway.protoc_gen_swagger.options.openapiv2_field] < %s > >
+					>
+				>
+				service <
+					name: "ExampleService"
+					method < name: "Echo" input_type: ".example.ExampleMessage" output_type: ".example.ExampleMessage" >
+				>
+			>
+		`, spec.fieldType, spec.options)
+		var req plugin.CodeGeneratorRequest
+		if err := proto.UnmarshalText(src, &req); err != nil {
+			t.Fatalf("proto.UnmarshalText(%s, &req) failed with %v; want success", src, err)
+		}
+		reg := descriptor.NewRegistry()
+		reg.SetGenerateSchemaValidation(true)
+		reg.AddExternalHTTPRule(".example.ExampleService.Echo", &annotations.HttpRule{
+			Pattern: &annotations.HttpRule_Post{Post: "/v1/example"},
+			Body:    "*",
+		})
+		if err := reg.Load(&req); err != nil {
+			t.Fatalf("reg.Load(%s) failed with %v; want success", src, err)
+		}
+		file, err := reg.LookupFile("example.proto")
+		if err != nil {
+			t.Fatalf("reg.LookupFile(%q) failed with %v; want success", "example.proto", err)
+		}
+		if got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, reg); err == nil {
+			t.Errorf("applyTemplate(%#v) = %s; want an error; options=%s", file, got, spec.options)
+		}
+	}
+}
+
+func TestApplyTemplateHTTPBodyRequest(t *testing.T) {
+	const src = `
+		file_to_generate: "example.proto"
+		proto_file <
+			name: "google/api/httpbody.proto"
+			package: "google.api"
+			syntax: "proto3"
+			options < go_package: "google.golang.org/genproto/googleapis/api/httpbody;httpbody" >
+			message_type <
+				name: "HttpBody"
+				field < name: "content_type" json_name: "contentType" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING >
+				field < name: "data" json_name: "data" number: 2 label: LABEL_OPTIONAL type: TYPE_BYTES >
+			>
+		>
+		proto_file <
+			name: "example.proto"
+			package: "example"
+			syntax: "proto3"
+			dependency: "google/api/httpbody.proto"
+			options < go_package: "example.com/path/to/example;example_pb" >
+			message_type <
+				name: "UploadRequest"
+				field < name: "name" json_name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING >
+				field < name: "body" json_name: "body" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.api.HttpBody" >
+			>
+			service <
+				name: "ExampleService"
+				method < name: "Upload" input_type: ".example.UploadRequest" output_type: ".google.api.HttpBody" >
+				method < name: "UploadRaw" input_type: ".google.api.HttpBody" output_type: ".google.api.HttpBody" >
+			>
+		>
+	`
+	var req plugin.CodeGeneratorRequest
+	if err := proto.UnmarshalText(src, &req); err != nil {
+		t.Fatalf("proto.UnmarshalText(%s, &req) failed with %v; want success", src, err)
+	}
+	reg := descriptor.NewRegistry()
+	reg.AddExternalHTTPRule(".example.ExampleService.Upload", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/{name}"},
+		Body:    "body",
+	})
+	reg.AddExternalHTTPRule(".example.ExampleService.UploadRaw", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/raw"},
+		Body:    "*",
+	})
+	if err := reg.Load(&req); err != nil {
+		t.Fatalf("reg.Load(%s) failed with %v; want success", src, err)
+	}
+	file, err := reg.LookupFile("example.proto")
+	if err != nil {
+		t.Fatalf("reg.LookupFile(%q) failed with %v; want success", "example.proto", err)
+	}
+
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, reg)
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	for _, want := range []string{
+		`if err := runtime.DecodeHTTPBody(req, &protoReq.Body); err != nil {`,
+		`if err := runtime.DecodeHTTPBody(req, &protoReq); err != nil {`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
+		}
+	}
+	if notwanted := `marshaler.NewDecoder(req.Body)`; strings.Contains(got, notwanted) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notwanted)
+	}
+}
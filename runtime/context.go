@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
@@ -49,15 +48,35 @@ func decodeBinHeader(v string) ([]byte, error) {
 	return base64.RawStdEncoding.DecodeString(v)
 }
 
+// outgoingHeaderValue returns the HTTP header value of the metadata "key" with "value".
+// The values of binary keys are base64-encoded.
+func outgoingHeaderValue(key, value string) string {
+	if strings.HasSuffix(strings.ToLower(key), strings.ToLower(metadataHeaderBinarySuffix)) {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	return value
+}
+
 /*
 AnnotateContext adds context information such as metadata from the request.
 
 At a minimum, the RemoteAddr is included in the fashion of "X-Forwarded-For",
 except that the forwarded destination is not another HTTP service but rather
 a gRPC service.
+See WithTrustedProxies for how the forwarding headers are handled behind proxies.
+
+The W3C Trace Context and Baggage headers, i.e. "traceparent", "tracestate" and "baggage", are also forwarded
+if "traceparent" is valid. See WithTracer for how to trace the RPCs.
+
+It returns a ResourceExhausted error if the request is over the limits given by WithLimits.
 */
 func AnnotateContext(ctx context.Context, mux *ServeMux, req *http.Request) (context.Context, error) {
-	var pairs []string
+	var (
+		// pairs are written by the gateway.
+		pairs []string
+		// incoming are taken from the headers.
+		incoming []string
+	)
 	timeout := DefaultContextTimeout
 	if tm := req.Header.Get(metadataGrpcTimeout); tm != "" {
 		var err error
@@ -72,9 +91,17 @@ func AnnotateContext(ctx context.Context, mux *ServeMux, req *http.Request) (con
 			key = textproto.CanonicalMIMEHeaderKey(key)
 			// For backwards-compatibility, pass through 'authorization' header with no prefix.
 			if key == "Authorization" {
-				pairs = append(pairs, "authorization", val)
+				incoming = append(incoming, "authorization", val)
+			}
+			if key == "If-Match" && mux.etag {
+				pairs = append(pairs, metadataIfMatch, val)
 			}
 			if h, ok := mux.incomingHeaderMatcher(key); ok {
+				// The prefix is reserved for the permanent headers.
+				if strings.HasPrefix(key, MetadataHeaderPrefix) && strings.HasPrefix(strings.ToLower(h), MetadataPrefix) {
+					mux.Logger().Debugf(ctx, "dropped incoming metadata %q with a reserved prefix", h)
+					continue
+				}
 				// Handles "-bin" metadata in grpc, since grpc will do another base64
 				// encode before sending to server, we need to decode it first.
 				if strings.HasSuffix(key, metadataHeaderBinarySuffix) {
@@ -85,39 +112,68 @@ func AnnotateContext(ctx context.Context, mux *ServeMux, req *http.Request) (con
 
 					val = string(b)
 				}
-				pairs = append(pairs, h, val)
+				incoming = append(incoming, h, val)
 			}
 		}
 	}
-	if host := req.Header.Get(xForwardedHost); host != "" {
-		pairs = append(pairs, strings.ToLower(xForwardedHost), host)
-	} else if req.Host != "" {
-		pairs = append(pairs, strings.ToLower(xForwardedHost), req.Host)
-	}
+	incoming = append(incoming, mux.incomingCookiePairs(req)...)
+	if mux.trustedProxies != nil {
+		pairs = append(pairs, mux.forwardedPairs(ctx, req)...)
+	} else {
+		if host := req.Header.Get(xForwardedHost); host != "" {
+			pairs = append(pairs, strings.ToLower(xForwardedHost), host)
+		} else if req.Host != "" {
+			pairs = append(pairs, strings.ToLower(xForwardedHost), req.Host)
+		}
 
-	if addr := req.RemoteAddr; addr != "" {
-		if remoteIP, _, err := net.SplitHostPort(addr); err == nil {
-			if fwd := req.Header.Get(xForwardedFor); fwd == "" {
-				pairs = append(pairs, strings.ToLower(xForwardedFor), remoteIP)
+		if addr := req.RemoteAddr; addr != "" {
+			if remoteIP, _, err := net.SplitHostPort(addr); err == nil {
+				if fwd := req.Header.Get(xForwardedFor); fwd == "" {
+					pairs = append(pairs, strings.ToLower(xForwardedFor), remoteIP)
+				} else {
+					pairs = append(pairs, strings.ToLower(xForwardedFor), fmt.Sprintf("%s, %s", fwd, remoteIP))
+				}
 			} else {
-				pairs = append(pairs, strings.ToLower(xForwardedFor), fmt.Sprintf("%s, %s", fwd, remoteIP))
+				mux.Logger().Warningf(ctx, "invalid remote addr: %s", addr)
 			}
-		} else {
-			grpclog.Infof("invalid remote addr: %s", addr)
 		}
 	}
 
+	call := rpcCallFromContext(ctx, req)
+	if call != nil {
+		call.span.RPCMethod, _ = RPCMethodFromContext(ctx)
+		ctx = context.WithValue(ctx, rpcCallKey{}, call)
+	}
+	if id, ok := RequestIDFromContext(req.Context()); ok {
+		ctx = NewRequestIDContext(ctx, id)
+		pairs = append(pairs, strings.ToLower(RequestIDHeader), id)
+	}
+	ctx, tc := mux.startSpan(ctx, call, traceContextFromRequest(req))
+	pairs = append(pairs, tc.pairs()...)
+
 	if timeout != 0 {
 		ctx, _ = context.WithTimeout(ctx, timeout)
 	}
-	if len(pairs) == 0 {
-		return ctx, nil
+	if mux.logger != nil {
+		ctx = context.WithValue(ctx, loggerKey{}, mux.logger)
+	}
+	if mux.requestValidator != nil {
+		ctx = context.WithValue(ctx, requestValidatorKey{}, mux.requestValidator)
+	}
+	if len(pairs) == 0 && len(incoming) == 0 {
+		return mux.admit(ctx, req, nil)
 	}
 	md := metadata.Pairs(pairs...)
 	for _, mda := range mux.metadataAnnotators {
 		md = metadata.Join(md, mda(ctx, req))
 	}
-	return metadata.NewOutgoingContext(ctx, md), nil
+	// The metadata written by the gateway and the annotators win over the headers.
+	incoming, err := mux.filterIncomingMetadata(ctx, incoming, md)
+	if err != nil {
+		return nil, err
+	}
+	md = metadata.Join(metadata.Pairs(incoming...), md)
+	return mux.admit(metadata.NewOutgoingContext(ctx, md), req, md)
 }
 
 // ServerMetadata consists of metadata sent from gRPC server.
@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataSetCookie is the header metadata key with which gRPC servers set cookies on clients.
+// Each value is a JSON object built by CookieMetadata, which the gateway turns into a Set-Cookie header.
+// It is not forwarded to clients as a Grpc-Metadata-* header.
+const MetadataSetCookie = "grpcgateway-set-cookie"
+
+// WithIncomingCookie returns a ServeMuxOption which makes AnnotateContext forward the value of
+// the cookie "name" of requests as the metadata "key".
+//
+// The metadata is taken from the request like the headers, so it is subject to WithIncomingMetadataAllowList,
+// WithIncomingMetadataDenyList and WithIncomingMetadataLimits, and the WithMetadata annotators win over it.
+func WithIncomingCookie(name, key string) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.incomingCookies = append(serveMux.incomingCookies, cookieMapping{name: name, key: strings.ToLower(key)})
+	}
+}
+
+type cookieMapping struct {
+	name string
+	key  string
+}
+
+// incomingCookiePairs returns the metadata pairs mapped from the cookies of "req".
+func (s *ServeMux) incomingCookiePairs(req *http.Request) []string {
+	var pairs []string
+	for _, m := range s.incomingCookies {
+		for _, c := range req.Cookies() {
+			if c.Name == m.name {
+				pairs = append(pairs, m.key, c.Value)
+			}
+		}
+	}
+	return pairs
+}
+
+// cookieSpec is the JSON representation of a cookie in MetadataSetCookie.
+type cookieSpec struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	MaxAge   int    `json:"max_age,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+	HTTPOnly bool   `json:"http_only,omitempty"`
+	SameSite string `json:"same_site,omitempty"`
+}
+
+var sameSiteNames = map[http.SameSite]string{
+	http.SameSiteLaxMode:    "lax",
+	http.SameSiteStrictMode: "strict",
+	http.SameSiteNoneMode:   "none",
+}
+
+// CookieMetadata returns the header metadata with which gRPC servers written in Go set "c" on clients, e.g.
+//
+//	grpc.SetHeader(ctx, runtime.CookieMetadata(&http.Cookie{Name: "session", Value: id, HttpOnly: true}))
+//
+// Servers in other languages can add the JSON object with the fields "name", "value", "path", "domain",
+// "expires" (RFC 3339), "max_age" (seconds, -1 to delete the cookie), "secure", "http_only" and
+// "same_site" ("lax", "strict" or "none") to the header metadata MetadataSetCookie.
+func CookieMetadata(c *http.Cookie) metadata.MD {
+	spec := cookieSpec{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   c.MaxAge,
+		Secure:   c.Secure,
+		HTTPOnly: c.HttpOnly,
+		SameSite: sameSiteNames[c.SameSite],
+	}
+	if !c.Expires.IsZero() {
+		spec.Expires = c.Expires.UTC().Format(time.RFC3339)
+	}
+	buf, _ := json.Marshal(spec)
+	return metadata.Pairs(MetadataSetCookie, string(buf))
+}
+
+// parseCookieMetadata parses a value of MetadataSetCookie.
+func parseCookieMetadata(val string) (*http.Cookie, error) {
+	var spec cookieSpec
+	if err := json.Unmarshal([]byte(val), &spec); err != nil {
+		return nil, err
+	}
+	c := &http.Cookie{
+		Name:     spec.Name,
+		Value:    spec.Value,
+		Path:     spec.Path,
+		Domain:   spec.Domain,
+		MaxAge:   spec.MaxAge,
+		Secure:   spec.Secure,
+		HttpOnly: spec.HTTPOnly,
+	}
+	if spec.Expires != "" {
+		t, err := time.Parse(time.RFC3339, spec.Expires)
+		if err != nil {
+			return nil, err
+		}
+		c.Expires = t
+	}
+	if spec.SameSite != "" {
+		var ok bool
+		for mode, name := range sameSiteNames {
+			if strings.EqualFold(spec.SameSite, name) {
+				c.SameSite, ok = mode, true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown same_site: %q", spec.SameSite)
+		}
+	}
+	// http.Cookie.String returns an empty string for invalid names.
+	if c.String() == "" {
+		return nil, fmt.Errorf("invalid cookie name: %q", spec.Name)
+	}
+	return c, nil
+}
+
+// forwardResponseCookies adds a Set-Cookie header to "w" for each cookie set by the gRPC server with MetadataSetCookie.
+func forwardResponseCookies(ctx context.Context, w http.ResponseWriter, mux *ServeMux, md ServerMetadata) {
+	for _, val := range md.HeaderMD[MetadataSetCookie] {
+		c, err := parseCookieMetadata(val)
+		if err != nil {
+			mux.Logger().Warningf(ctx, "Invalid cookie in %s metadata: %v", MetadataSetCookie, err)
+			continue
+		}
+		http.SetCookie(w, c)
+	}
+}
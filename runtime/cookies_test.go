@@ -0,0 +1,81 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAnnotateContextWithIncomingCookie(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/foo", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest failed with %v; want success", err)
+	}
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	mux := runtime.NewServeMux(runtime.WithIncomingCookie("session", "X-Session-Id"))
+	ctx, err := runtime.AnnotateContext(context.Background(), mux, req)
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if got, want := md["x-session-id"], []string{"abc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`md["x-session-id"] = %q; want %q`, got, want)
+	}
+	if got, ok := md["theme"]; ok {
+		t.Errorf(`md["theme"] = %q; want no value`, got)
+	}
+}
+
+func TestForwardResponseServerMetadataWithCookies(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	md := metadata.Join(
+		runtime.CookieMetadata(&http.Cookie{
+			Name:     "session",
+			Value:    "abc",
+			Path:     "/",
+			Expires:  expires,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteStrictMode,
+		}),
+		metadata.Pairs(runtime.MetadataSetCookie, `{"name":"theme","value":"dark","max_age":-1,"same_site":"Lax"}`),
+		metadata.Pairs(runtime.MetadataSetCookie, `{"name":"bad name","value":"x"}`),
+		metadata.Pairs(runtime.MetadataSetCookie, `not json`),
+	)
+
+	logger := new(recordingLogger)
+	ctx := runtime.NewRPCMethodContext(context.Background(), "/example.ExampleService/Example")
+	w := httptest.NewRecorder()
+	runtime.ForwardResponseServerMetadata(ctx, w, runtime.NewServeMux(runtime.WithLogger(logger)), runtime.ServerMetadata{HeaderMD: md})
+
+	got := w.Header()["Set-Cookie"]
+	want := []string{
+		"session=abc; Path=/; Expires=Wed, 02 Jan 2030 03:04:05 GMT; HttpOnly; Secure; SameSite=Strict",
+		"theme=dark; Max-Age=0; SameSite=Lax",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Set-Cookie = %q; want %q", got, want)
+	}
+	for k := range w.Header() {
+		if strings.HasPrefix(k, runtime.MetadataHeaderPrefix) {
+			t.Errorf("w.Header() has %q; want the cookie metadata not to be forwarded as a header", k)
+		}
+	}
+	if got, want := len(logger.entries), 2; got != want {
+		t.Fatalf("len(logger.entries) = %d; want %d", got, want)
+	}
+	for _, e := range logger.entries {
+		if e.level != "warning" || e.method != "/example.ExampleService/Example" {
+			t.Errorf("logger.entries has %+v; want warnings of /example.ExampleService/Example", e)
+		}
+	}
+}
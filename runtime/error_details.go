@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"context"
+	"encoding/base64"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// statusDetailsHeader is the header of error responses which has the base64-encoded google.rpc.Status
+// if the status has details.
+const statusDetailsHeader = "Grpc-Status-Details-Bin"
+
+// HTTPErrorDetails is the part of an HTTP error response which is derived from the details of a gRPC status.
+type HTTPErrorDetails struct {
+	// Status is the HTTP status code to reply with.
+	Status int
+	// Header is added to the header of the response.
+	Header http.Header
+	// Domain is the logical grouping to which Reason belongs. It comes from google.rpc.ErrorInfo.
+	Domain string
+	// Reason is the reason of the error. It comes from google.rpc.ErrorInfo.
+	Reason string
+	// FieldViolations is the list of invalid fields in the request. It comes from google.rpc.BadRequest.
+	FieldViolations []*errdetails.BadRequest_FieldViolation
+	// RequestID identifies the request. It comes from google.rpc.RequestInfo.
+	RequestID string
+}
+
+// ErrorDetailHandlerFunc reflects a detail of a gRPC status on "resp".
+type ErrorDetailHandlerFunc func(ctx context.Context, detail proto.Message, resp *HTTPErrorDetails)
+
+var defaultErrorDetailHandlers = map[string]ErrorDetailHandlerFunc{
+	proto.MessageName(&errdetails.RetryInfo{}):           handleRetryInfo,
+	proto.MessageName(&errdetails.BadRequest{}):          handleBadRequest,
+	proto.MessageName(&errdetails.ErrorInfo{}):           handleErrorInfo,
+	proto.MessageName(&errdetails.QuotaFailure{}):        handleQuotaFailure,
+	proto.MessageName(&errdetails.PreconditionFailure{}): handlePreconditionFailure,
+	proto.MessageName(&errdetails.RequestInfo{}):         handleRequestInfo,
+}
+
+// WithErrorDetailHandler returns a ServeMuxOption which registers "fn" for the error details of the type of "detail".
+//
+// By default google.rpc.RetryInfo, google.rpc.BadRequest, google.rpc.ErrorInfo, google.rpc.QuotaFailure,
+// google.rpc.PreconditionFailure and google.rpc.RequestInfo are handled. Passing nil for "fn" disables the handling of the type.
+func WithErrorDetailHandler(detail proto.Message, fn ErrorDetailHandlerFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		if serveMux.errorDetailHandlers == nil {
+			serveMux.errorDetailHandlers = make(map[string]ErrorDetailHandlerFunc)
+		}
+		serveMux.errorDetailHandlers[proto.MessageName(detail)] = fn
+	}
+}
+
+func (s *ServeMux) errorDetailHandler(name string) ErrorDetailHandlerFunc {
+	if s != nil {
+		if fn, ok := s.errorDetailHandlers[name]; ok {
+			return fn
+		}
+	}
+	return defaultErrorDetailHandlers[name]
+}
+
+// HTTPErrorDetailsFromStatus runs the error detail handlers registered to "mux" against the details of "s".
+// It is intended to be used by implementations of HTTPError.
+// It also records "s" as the status of the RPC for the Tracer given to WithTracer.
+func HTTPErrorDetailsFromStatus(ctx context.Context, mux *ServeMux, s *status.Status) *HTTPErrorDetails {
+	rpcCallFromContext(ctx, nil).setStatus(s)
+	resp := &HTTPErrorDetails{
+		Status: mux.HTTPStatusFromCode(s.Code()),
+		Header: make(http.Header),
+	}
+	for _, d := range s.Details() {
+		detail, ok := d.(proto.Message)
+		if !ok {
+			mux.Logger().Warningf(ctx, "Failed to unmarshal error detail: %v", d)
+			continue
+		}
+		if fn := mux.errorDetailHandler(proto.MessageName(detail)); fn != nil {
+			fn(ctx, detail, resp)
+		}
+	}
+	if len(s.Proto().GetDetails()) > 0 {
+		// Exposes the whole status as gRPC does in the grpc-status-details-bin trailer.
+		if buf, err := proto.Marshal(s.Proto()); err == nil {
+			resp.Header.Set(statusDetailsHeader, base64.StdEncoding.EncodeToString(buf))
+		}
+	}
+	return resp
+}
+
+func handleRetryInfo(_ context.Context, detail proto.Message, resp *HTTPErrorDetails) {
+	d, err := ptypes.Duration(detail.(*errdetails.RetryInfo).GetRetryDelay())
+	if err != nil || d < 0 {
+		return
+	}
+	secs := int64(math.Ceil(d.Seconds()))
+	resp.Header.Set("Retry-After", strconv.FormatInt(secs, 10))
+}
+
+func handleBadRequest(_ context.Context, detail proto.Message, resp *HTTPErrorDetails) {
+	resp.FieldViolations = append(resp.FieldViolations, detail.(*errdetails.BadRequest).GetFieldViolations()...)
+}
+
+func handleErrorInfo(_ context.Context, detail proto.Message, resp *HTTPErrorDetails) {
+	info := detail.(*errdetails.ErrorInfo)
+	resp.Domain = info.GetDomain()
+	resp.Reason = info.GetReason()
+}
+
+func handleQuotaFailure(_ context.Context, _ proto.Message, resp *HTTPErrorDetails) {
+	resp.Status = http.StatusTooManyRequests
+}
+
+func handlePreconditionFailure(_ context.Context, _ proto.Message, resp *HTTPErrorDetails) {
+	resp.Status = http.StatusPreconditionFailed
+}
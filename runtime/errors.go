@@ -7,8 +7,8 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -52,7 +52,7 @@ func HTTPStatusFromCode(code codes.Code) int {
 		return http.StatusInternalServerError
 	}
 
-	grpclog.Infof("Unknown gRPC error code: %v", code)
+	defaultLogger.Warningf(context.Background(), "Unknown gRPC error code: %v", code)
 	return http.StatusInternalServerError
 }
 
@@ -65,13 +65,21 @@ var (
 )
 
 type errorBody struct {
-	Error   string     `protobuf:"bytes,1,name=error" json:"error"`
+	Error string `protobuf:"bytes,1,name=error" json:"error"`
 	// This is to make the error more compatible with users that expect errors to be Status objects:
 	// https://github.com/grpc/grpc/blob/master/src/proto/grpc/status/status.proto
 	// It should be the exact same message as the Error field.
 	Message string     `protobuf:"bytes,1,name=message" json:"message"`
 	Code    int32      `protobuf:"varint,2,name=code" json:"code"`
 	Details []*any.Any `protobuf:"bytes,3,rep,name=details" json:"details,omitempty"`
+	// Domain and Reason come from google.rpc.ErrorInfo in the details.
+	Domain string `protobuf:"bytes,4,opt,name=domain" json:"domain,omitempty"`
+	Reason string `protobuf:"bytes,5,opt,name=reason" json:"reason,omitempty"`
+	// FieldViolations come from google.rpc.BadRequest in the details.
+	// They are rendered here so that they look the same regardless of the Marshaler.
+	FieldViolations []*errdetails.BadRequest_FieldViolation `protobuf:"bytes,6,rep,name=field_violations,json=fieldViolations" json:"fieldViolations,omitempty"`
+	// RequestId comes from google.rpc.RequestInfo in the details, e.g. the one added with WithRequestID.
+	RequestId string `protobuf:"bytes,7,opt,name=request_id,json=requestId" json:"requestId,omitempty"`
 }
 
 // Make this also conform to proto.Message for builtin JSONPb Marshaler
@@ -80,11 +88,14 @@ func (e *errorBody) String() string { return proto.CompactTextString(e) }
 func (*errorBody) ProtoMessage()    {}
 
 // DefaultHTTPError is the default implementation of HTTPError.
-// If "err" is an error from gRPC system, the function replies with the status code mapped by HTTPStatusFromCode.
+// If "err" is an error from gRPC system, the function replies with the status code mapped by ServeMux.HTTPStatusFromCode.
 // If otherwise, it replies with http.StatusInternalServerError.
 //
 // The response body returned by this function is a JSON object,
 // which contains a member whose key is "error" and whose value is err.Error().
+//
+// The details of the status are reflected on the response by the handlers registered with WithErrorDetailHandler.
+// The ID of the request given by WithRequestID is added to the details and to the object as "requestId".
 func DefaultHTTPError(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
 	const fallback = `{"error": "failed to marshal error message"}`
 
@@ -95,38 +106,48 @@ func DefaultHTTPError(ctx context.Context, mux *ServeMux, marshaler Marshaler, w
 	if !ok {
 		s = status.New(codes.Unknown, err.Error())
 	}
+	s = statusWithRequestID(ctx, s)
 
+	details := HTTPErrorDetailsFromStatus(ctx, mux, s)
 	body := &errorBody{
-		Error:   s.Message(),
-		Message: s.Message(),
-		Code:    int32(s.Code()),
-		Details: s.Proto().GetDetails(),
+		Error:           s.Message(),
+		Message:         s.Message(),
+		Code:            int32(s.Code()),
+		Details:         s.Proto().GetDetails(),
+		Domain:          details.Domain,
+		Reason:          details.Reason,
+		FieldViolations: details.FieldViolations,
+		RequestId:       details.RequestID,
 	}
 
 	buf, merr := marshaler.Marshal(body)
 	if merr != nil {
-		grpclog.Infof("Failed to marshal error message %q: %v", body, merr)
+		mux.Logger().Errorf(ctx, "Failed to marshal error message %q: %v", body, merr)
 		w.WriteHeader(http.StatusInternalServerError)
 		if _, err := io.WriteString(w, fallback); err != nil {
-			grpclog.Infof("Failed to write response: %v", err)
+			mux.Logger().Infof(ctx, "Failed to write response: %v", err)
 		}
 		return
 	}
 
 	md, ok := ServerMetadataFromContext(ctx)
 	if !ok {
-		grpclog.Infof("Failed to extract ServerMetadata from context")
+		mux.Logger().Errorf(ctx, "Failed to extract ServerMetadata from context")
 	}
 
-	handleForwardResponseServerMetadata(w, mux, md)
-	handleForwardResponseTrailerHeader(w, md)
-	st := HTTPStatusFromCode(s.Code())
-	w.WriteHeader(st)
+	ForwardResponseServerMetadata(ctx, w, mux, md)
+	ForwardResponseTrailerHeader(w, mux, md)
+	for k, vs := range details.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(details.Status)
 	if _, err := w.Write(buf); err != nil {
-		grpclog.Infof("Failed to write response: %v", err)
+		mux.Logger().Infof(ctx, "Failed to write response: %v", err)
 	}
 
-	handleForwardResponseTrailer(w, md)
+	ForwardResponseTrailer(w, mux, md)
 }
 
 // DefaultOtherErrorHandler is the default implementation of OtherErrorHandler.
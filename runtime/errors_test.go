@@ -2,6 +2,7 @@ package runtime_test
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,8 +10,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -85,3 +89,128 @@ func TestDefaultHTTPError(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultHTTPErrorWithDetails(t *testing.T) {
+	ctx := context.Background()
+
+	withDetails := func(c codes.Code, details ...proto.Message) error {
+		s, err := status.New(c, "error with details").WithDetails(details...)
+		if err != nil {
+			t.Fatalf("status.WithDetails(%v) failed with %v; want success", details, err)
+		}
+		return s.Err()
+	}
+
+	for _, spec := range []struct {
+		name            string
+		opts            []runtime.ServeMuxOption
+		err             error
+		status          int
+		retryAfter      string
+		domain          string
+		reason          string
+		fieldViolations int
+	}{
+		{
+			name: "retry info",
+			err: withDetails(codes.Unavailable, &errdetails.RetryInfo{
+				RetryDelay: &duration.Duration{Seconds: 1, Nanos: 500000000},
+			}),
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "2",
+		},
+		{
+			name: "bad request",
+			err: withDetails(codes.FailedPrecondition, &errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "name", Description: "must not be empty"},
+					{Field: "age", Description: "must be positive"},
+				},
+			}),
+			status:          http.StatusPreconditionFailed,
+			fieldViolations: 2,
+		},
+		{
+			name: "error info",
+			err: withDetails(codes.PermissionDenied, &errdetails.ErrorInfo{
+				Domain: "example.com",
+				Reason: "API_DISABLED",
+			}),
+			status: http.StatusForbidden,
+			domain: "example.com",
+			reason: "API_DISABLED",
+		},
+		{
+			name:       "quota failure",
+			err:        withDetails(codes.Unknown, &errdetails.QuotaFailure{}, &errdetails.RetryInfo{RetryDelay: &duration.Duration{Seconds: 30}}),
+			status:     http.StatusTooManyRequests,
+			retryAfter: "30",
+		},
+		{
+			name:   "precondition failure",
+			err:    withDetails(codes.Unknown, &errdetails.PreconditionFailure{}),
+			status: http.StatusPreconditionFailed,
+		},
+		{
+			name: "custom handler",
+			opts: []runtime.ServeMuxOption{
+				runtime.WithErrorDetailHandler(&errdetails.PreconditionFailure{}, func(_ context.Context, _ proto.Message, resp *runtime.HTTPErrorDetails) {
+					resp.Status = http.StatusConflict
+				}),
+			},
+			err:    withDetails(codes.FailedPrecondition, &errdetails.PreconditionFailure{}),
+			status: http.StatusConflict,
+		},
+		{
+			name: "disabled handler",
+			opts: []runtime.ServeMuxOption{
+				runtime.WithErrorDetailHandler(&errdetails.QuotaFailure{}, nil),
+			},
+			err:    withDetails(codes.Unknown, &errdetails.QuotaFailure{}),
+			status: http.StatusInternalServerError,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("", "", nil)
+			runtime.DefaultHTTPError(ctx, runtime.NewServeMux(spec.opts...), &runtime.JSONBuiltin{}, w, req, spec.err)
+
+			if got, want := w.Code, spec.status; got != want {
+				t.Errorf("w.Code = %d; want %d", got, want)
+			}
+			if got, want := w.Header().Get("Retry-After"), spec.retryAfter; got != want {
+				t.Errorf(`w.Header().Get("Retry-After") = %q; want %q`, got, want)
+			}
+
+			var body struct {
+				Domain          string
+				Reason          string
+				FieldViolations []map[string]string
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("json.Unmarshal(%q, &body) failed with %v; want success", w.Body.Bytes(), err)
+			}
+			if got, want := body.Domain, spec.domain; got != want {
+				t.Errorf("body.Domain = %q; want %q", got, want)
+			}
+			if got, want := body.Reason, spec.reason; got != want {
+				t.Errorf("body.Reason = %q; want %q", got, want)
+			}
+			if got, want := len(body.FieldViolations), spec.fieldViolations; got != want {
+				t.Errorf("len(body.FieldViolations) = %d; want %d", got, want)
+			}
+
+			buf, err := base64.StdEncoding.DecodeString(w.Header().Get("Grpc-Status-Details-Bin"))
+			if err != nil {
+				t.Fatalf("base64.StdEncoding.DecodeString(%q) failed with %v; want success", w.Header().Get("Grpc-Status-Details-Bin"), err)
+			}
+			var got spb.Status
+			if err := proto.Unmarshal(buf, &got); err != nil {
+				t.Fatalf("proto.Unmarshal(%q, &got) failed with %v; want success", buf, err)
+			}
+			if want := status.Convert(spec.err).Proto(); !proto.Equal(&got, want) {
+				t.Errorf("Grpc-Status-Details-Bin = %v; want %v", &got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// metadataIfMatch is the metadata key which If-Match headers are forwarded with when ETags are enabled.
+const metadataIfMatch = "if-match"
+
+// ETagFunc returns the entity tag of "resp". It returns false if "resp" has no entity tag.
+// The tag may be given with or without the surrounding double quotes.
+type ETagFunc func(ctx context.Context, resp proto.Message) (etag string, ok bool)
+
+// WithETag returns a ServeMuxOption which enables ETags for the responses to GET and HEAD requests.
+//
+// The ETag header is set to the tag given by "fn". If "fn" is nil, a strong tag is computed
+// from the hash of the marshaled response, which requires the response to be buffered.
+// Requests whose If-None-Match header matches the tag are answered with 304 Not Modified without the body.
+//
+// The If-Match headers of requests are forwarded to gRPC servers as "if-match" metadata
+// so that servers can implement conditional updates.
+func WithETag(fn ETagFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.etag = true
+		serveMux.etagFunc = fn
+	}
+}
+
+// ETagFromMetadata returns an ETagFunc which takes the tag from the header metadata "key" sent by gRPC servers.
+func ETagFromMetadata(key string) ETagFunc {
+	key = strings.ToLower(key)
+	return func(ctx context.Context, resp proto.Message) (string, bool) {
+		md, ok := ServerMetadataFromContext(ctx)
+		if !ok {
+			return "", false
+		}
+		vals := md.HeaderMD[key]
+		if len(vals) == 0 || vals[0] == "" {
+			return "", false
+		}
+		return vals[0], true
+	}
+}
+
+// ETagFromField returns an ETagFunc which takes the tag from the scalar field "name" of responses.
+// "name" is the field name in the proto definition.
+func ETagFromField(name string) ETagFunc {
+	return func(ctx context.Context, resp proto.Message) (string, bool) {
+		m := reflect.ValueOf(resp)
+		if m.Kind() != reflect.Ptr || m.IsNil() || m.Elem().Kind() != reflect.Struct {
+			return "", false
+		}
+		m = m.Elem()
+		for i, p := range proto.GetProperties(m.Type()).Prop {
+			if p.OrigName != name {
+				continue
+			}
+			f := m.Field(i)
+			switch f.Kind() {
+			case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Struct:
+				return "", false
+			}
+			if v := fmt.Sprint(f.Interface()); v != "" {
+				return v, true
+			}
+			return "", false
+		}
+		return "", false
+	}
+}
+
+// etagApplies returns true if ETags are enabled in "mux" for "r".
+func etagApplies(mux *ServeMux, r *http.Request) bool {
+	return mux != nil && mux.etag && (r.Method == "GET" || r.Method == "HEAD")
+}
+
+// responseETag returns the ETag of "resp".
+// It also returns the result of "marshal" if it has marshaled the response to compute the tag.
+func responseETag(ctx context.Context, mux *ServeMux, resp proto.Message, marshal func() ([]byte, error)) (string, []byte, error) {
+	if mux.etagFunc != nil {
+		etag, ok := mux.etagFunc(ctx, resp)
+		if !ok {
+			return "", nil, nil
+		}
+		return quoteETag(etag), nil, nil
+	}
+	data, err := marshal()
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`, data, nil
+}
+
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagMatches reports whether the value of If-None-Match header "header" matches "etag" with the weak comparison.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
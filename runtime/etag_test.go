@@ -0,0 +1,168 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestForwardResponseMessageWithETag(t *testing.T) {
+	msg := &pb.SimpleMessage{Id: "foo", Num: 1}
+	for _, spec := range []struct {
+		name        string
+		fn          runtime.ETagFunc
+		header      metadata.MD
+		method      string
+		ifNoneMatch string
+
+		wantETag   string
+		wantStatus int
+		wantBody   bool
+	}{
+		{
+			name:       "hash",
+			method:     "GET",
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+		{
+			name:        "hash not modified",
+			method:      "GET",
+			ifNoneMatch: "*",
+			wantStatus:  http.StatusNotModified,
+		},
+		{
+			name:       "field",
+			fn:         runtime.ETagFromField("id"),
+			method:     "GET",
+			wantETag:   `"foo"`,
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+		{
+			name:        "field not modified",
+			fn:          runtime.ETagFromField("id"),
+			method:      "GET",
+			ifNoneMatch: `"bar", W/"foo"`,
+			wantETag:    `"foo"`,
+			wantStatus:  http.StatusNotModified,
+		},
+		{
+			name:        "field modified",
+			fn:          runtime.ETagFromField("id"),
+			method:      "GET",
+			ifNoneMatch: `"bar"`,
+			wantETag:    `"foo"`,
+			wantStatus:  http.StatusOK,
+			wantBody:    true,
+		},
+		{
+			name:        "metadata",
+			fn:          runtime.ETagFromMetadata("ETag"),
+			header:      metadata.Pairs("etag", `W/"v2"`),
+			method:      "HEAD",
+			ifNoneMatch: `"v2"`,
+			wantETag:    `W/"v2"`,
+			wantStatus:  http.StatusNotModified,
+		},
+		{
+			name:        "no metadata",
+			fn:          runtime.ETagFromMetadata("etag"),
+			method:      "GET",
+			ifNoneMatch: "*",
+			wantStatus:  http.StatusOK,
+			wantBody:    true,
+		},
+		{
+			name:        "post",
+			method:      "POST",
+			ifNoneMatch: "*",
+			wantStatus:  http.StatusOK,
+			wantBody:    true,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithETag(spec.fn))
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: spec.header})
+			req := httptest.NewRequest(spec.method, "http://example.com/v1/example", nil)
+			if spec.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", spec.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+
+			runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, w, req, msg)
+
+			if got, want := w.Code, spec.wantStatus; got != want {
+				t.Errorf("w.Code = %d; want %d", got, want)
+			}
+			etag := w.Header().Get("ETag")
+			if spec.wantETag != "" && etag != spec.wantETag {
+				t.Errorf("ETag = %q; want %q", etag, spec.wantETag)
+			}
+			if spec.fn == nil && spec.method != "POST" && etag == "" {
+				t.Errorf("ETag is empty; want the hash of the response")
+			}
+			if spec.method == "POST" && etag != "" {
+				t.Errorf("ETag = %q; want no ETag for POST", etag)
+			}
+			if got := w.Body.Len() > 0; got != spec.wantBody {
+				t.Errorf("w.Body = %q; want body %v", w.Body.String(), spec.wantBody)
+			}
+		})
+	}
+}
+
+func TestForwardResponseMessageWithHashETagIsStable(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithETag(nil))
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	forward := func(msg *pb.SimpleMessage, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "http://example.com/v1/example", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, w, req, msg)
+		return w
+	}
+
+	etag := forward(&pb.SimpleMessage{Id: "foo"}, "").Header().Get("ETag")
+	if got := forward(&pb.SimpleMessage{Id: "foo"}, etag); got.Code != http.StatusNotModified {
+		t.Errorf("w.Code = %d; want %d for the same response", got.Code, http.StatusNotModified)
+	}
+	if got := forward(&pb.SimpleMessage{Id: "bar"}, etag); got.Code != http.StatusOK || got.Header().Get("ETag") == etag {
+		t.Errorf("w.Code = %d, ETag = %q; want %d and a new ETag for a different response", got.Code, got.Header().Get("ETag"), http.StatusOK)
+	}
+}
+
+func TestAnnotateContextWithETagForwardsIfMatch(t *testing.T) {
+	for _, spec := range []struct {
+		opts []runtime.ServeMuxOption
+		want []string
+	}{
+		{
+			opts: []runtime.ServeMuxOption{runtime.WithETag(nil)},
+			want: []string{`"foo"`},
+		},
+		{
+			opts: nil,
+		},
+	} {
+		mux := runtime.NewServeMux(spec.opts...)
+		req, _ := http.NewRequest("PUT", "http://example.com/v1/example", nil)
+		req.Header.Set("If-Match", `"foo"`)
+
+		ctx, err := runtime.AnnotateContext(context.Background(), mux, req)
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+		}
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if got := md.Get("if-match"); len(got) != len(spec.want) || (len(got) > 0 && got[0] != spec.want[0]) {
+			t.Errorf("md[\"if-match\"] = %q; want %q", got, spec.want)
+		}
+	}
+}
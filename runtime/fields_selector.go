@@ -0,0 +1,160 @@
+package runtime
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// fieldsSelector is a tree of field names parsed from a FieldMask.
+// A nil subtree selects the whole field.
+type fieldsSelector map[string]fieldsSelector
+
+// WithFieldsSelector returns a ServeMuxOption which lets clients request partial responses.
+//
+// Clients can specify the fields to be included in responses with "$fields" query parameters
+// in the FieldMask syntax, e.g. "?$fields=id,author.name". "fields" without "$" is left to the request messages
+// which have fields of the name. The paths are relative to the response body,
+// so they work with response_body bindings. Paths through repeated and map fields apply to every element.
+// Both the proto field names and their JSON names are accepted. Unknown fields are ignored.
+//
+// It applies to ForwardResponseMessage and to each chunk of ForwardResponseStream.
+func WithFieldsSelector() ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.fieldsSelector = true
+	}
+}
+
+// fieldsSelectorFromRequest returns the fieldsSelector requested by "r", or nil if no fields are specified.
+func fieldsSelectorFromRequest(mux *ServeMux, r *http.Request) fieldsSelector {
+	if mux == nil || !mux.fieldsSelector || r == nil || !strings.Contains(r.URL.RawQuery, "fields=") {
+		return nil
+	}
+	var sel fieldsSelector
+	for _, v := range r.URL.Query()["$fields"] {
+		for _, path := range strings.Split(v, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if sel == nil {
+				sel = make(fieldsSelector)
+			}
+			sel.add(strings.Split(path, "."))
+		}
+	}
+	return sel
+}
+
+func (s fieldsSelector) add(path []string) {
+	node := s
+	for i, name := range path {
+		child, ok := node[name]
+		if ok && child == nil {
+			// The whole field has already been selected.
+			return
+		}
+		if i == len(path)-1 {
+			node[name] = nil
+			return
+		}
+		if !ok {
+			child = make(fieldsSelector)
+			node[name] = child
+		}
+		node = child
+	}
+}
+
+// applyFieldsSelector clears the fields of "v" which are not selected by "sel".
+// "v" is either a proto.Message or a value of a field of a message returned by XXX_ResponseBody.
+// It must not be shared with the gRPC server, i.e. it must come from a clone of the response.
+func applyFieldsSelector(v interface{}, sel fieldsSelector) {
+	if sel == nil || v == nil {
+		return
+	}
+	pruneValue(reflect.ValueOf(v), sel)
+}
+
+func pruneValue(v reflect.Value, sel fieldsSelector) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+		if _, ok := v.Interface().(proto.Message); ok {
+			pruneMessage(v.Elem(), sel)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Ptr {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			pruneValue(v.Index(i), sel)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Ptr {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			pruneValue(v.MapIndex(k), sel)
+		}
+	}
+}
+
+// pruneMessage clears the fields of the message struct "m" which are not selected by "sel".
+func pruneMessage(m reflect.Value, sel fieldsSelector) {
+	props := proto.GetProperties(m.Type())
+	for i, p := range props.Prop {
+		if strings.HasPrefix(p.Name, "XXX_") {
+			continue
+		}
+		f := m.Field(i)
+		if f.Kind() == reflect.Interface {
+			// oneof
+			if f.IsNil() {
+				continue
+			}
+			member := f.Elem()
+			var selected bool
+			for _, op := range props.OneofTypes {
+				if op.Type != member.Type() {
+					continue
+				}
+				var sub fieldsSelector
+				if sub, selected = sel.lookup(op.Prop); selected {
+					pruneValue(member.Elem().Field(0), sub)
+				}
+				break
+			}
+			if !selected {
+				f.Set(reflect.Zero(f.Type()))
+			}
+			continue
+		}
+
+		sub, ok := sel.lookup(p)
+		if !ok {
+			f.Set(reflect.Zero(f.Type()))
+			continue
+		}
+		if sub != nil {
+			pruneValue(f, sub)
+		}
+	}
+}
+
+// lookup returns the subtree for the field described by "p" and whether the field is selected.
+func (s fieldsSelector) lookup(p *proto.Properties) (fieldsSelector, bool) {
+	if sub, ok := s[p.OrigName]; ok {
+		return sub, true
+	}
+	if p.JSONName != "" {
+		if sub, ok := s[p.JSONName]; ok {
+			return sub, true
+		}
+	}
+	return nil, false
+}
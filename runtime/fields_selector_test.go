@@ -0,0 +1,149 @@
+package runtime_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	pb "github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+func newFieldsSelectorTestMessage() *pb.ABitOfEverything {
+	return &pb.ABitOfEverything{
+		Uuid:        "6EC2446F-7E89-4127-B3E6-5C05E6BECBA7",
+		StringValue: "strprefix/foo",
+		SingleNested: &pb.ABitOfEverything_Nested{
+			Name:   "foo",
+			Amount: 10,
+		},
+		Nested: []*pb.ABitOfEverything_Nested{
+			{Name: "bar", Amount: 20, Ok: pb.ABitOfEverything_Nested_TRUE},
+			{Name: "baz", Amount: 30},
+		},
+		MappedNestedValue: map[string]*pb.ABitOfEverything_Nested{
+			"a": {Name: "qux", Amount: 40},
+		},
+		OneofValue:     &pb.ABitOfEverything_OneofString{OneofString: "quux"},
+		TimestampValue: &timestamp.Timestamp{Seconds: 1},
+	}
+}
+
+func TestForwardResponseMessageWithFieldsSelector(t *testing.T) {
+	for _, spec := range []struct {
+		query string
+		want  *pb.ABitOfEverything
+	}{
+		{
+			query: "",
+			want:  newFieldsSelectorTestMessage(),
+		},
+		{
+			// "fields" is left to the request.
+			query: "fields=uuid",
+			want:  newFieldsSelectorTestMessage(),
+		},
+		{
+			query: "$fields=uuid",
+			want: &pb.ABitOfEverything{
+				Uuid: "6EC2446F-7E89-4127-B3E6-5C05E6BECBA7",
+			},
+		},
+		{
+			query: "$fields=uuid,single_nested.name",
+			want: &pb.ABitOfEverything{
+				Uuid:         "6EC2446F-7E89-4127-B3E6-5C05E6BECBA7",
+				SingleNested: &pb.ABitOfEverything_Nested{Name: "foo"},
+			},
+		},
+		{
+			query: "$fields=singleNested,singleNested.name,stringValue",
+			want: &pb.ABitOfEverything{
+				StringValue:  "strprefix/foo",
+				SingleNested: &pb.ABitOfEverything_Nested{Name: "foo", Amount: 10},
+			},
+		},
+		{
+			query: "$fields=nested.amount&$fields=mapped_nested_value.name",
+			want: &pb.ABitOfEverything{
+				Nested: []*pb.ABitOfEverything_Nested{
+					{Amount: 20},
+					{Amount: 30},
+				},
+				MappedNestedValue: map[string]*pb.ABitOfEverything_Nested{
+					"a": {Name: "qux"},
+				},
+			},
+		},
+		{
+			query: "$fields=oneof_string,unknown",
+			want: &pb.ABitOfEverything{
+				OneofValue: &pb.ABitOfEverything_OneofString{OneofString: "quux"},
+			},
+		},
+	} {
+		mux := runtime.NewServeMux(runtime.WithFieldsSelector())
+		ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+		req := httptest.NewRequest("GET", "http://example.com/v1/example?"+spec.query, nil)
+		w := httptest.NewRecorder()
+
+		resp := newFieldsSelectorTestMessage()
+		runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, w, req, resp)
+
+		if want := newFieldsSelectorTestMessage(); !proto.Equal(resp, want) {
+			t.Errorf("resp = %v; want %v as it was; query=%q", resp, want, spec.query)
+		}
+		var got pb.ABitOfEverything
+		if err := jsonpb.Unmarshal(w.Body, &got); err != nil {
+			t.Errorf("jsonpb.Unmarshal(%q, &got) failed with %v; want success; query=%q", w.Body.String(), err, spec.query)
+			continue
+		}
+		if !proto.Equal(&got, spec.want) {
+			t.Errorf("response = %v; want %v; query=%q", &got, spec.want, spec.query)
+		}
+	}
+}
+
+func TestForwardResponseMessageWithFieldsSelectorDisabled(t *testing.T) {
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	req := httptest.NewRequest("GET", "http://example.com/v1/example?$fields=uuid", nil)
+	w := httptest.NewRecorder()
+
+	runtime.ForwardResponseMessage(ctx, runtime.NewServeMux(), &runtime.JSONPb{}, w, req, newFieldsSelectorTestMessage())
+
+	var got pb.ABitOfEverything
+	if err := jsonpb.Unmarshal(w.Body, &got); err != nil {
+		t.Fatalf("jsonpb.Unmarshal(%q, &got) failed with %v; want success", w.Body.String(), err)
+	}
+	if want := newFieldsSelectorTestMessage(); !proto.Equal(&got, want) {
+		t.Errorf("response = %v; want %v", &got, want)
+	}
+}
+
+func TestForwardResponseStreamWithFieldsSelector(t *testing.T) {
+	msgs := []proto.Message{newFieldsSelectorTestMessage(), newFieldsSelectorTestMessage()}
+	recv := func() (proto.Message, error) {
+		if len(msgs) == 0 {
+			return nil, io.EOF
+		}
+		msg := msgs[0]
+		msgs = msgs[1:]
+		return msg, nil
+	}
+	mux := runtime.NewServeMux(runtime.WithFieldsSelector())
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	req := httptest.NewRequest("GET", "http://example.com/v1/example?$fields=nested.name", nil)
+	w := httptest.NewRecorder()
+
+	runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, req, recv)
+
+	want := `{"result":{"nested":[{"name":"bar"},{"name":"baz"}]}}`
+	if got := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(got) != 2 || got[0] != want || got[1] != want {
+		t.Errorf("w.Body = %q; want 2 chunks of %q", w.Body.String(), want)
+	}
+}
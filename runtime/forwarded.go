@@ -0,0 +1,256 @@
+package runtime
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const forwarded = "Forwarded"
+const xForwardedProto = "X-Forwarded-Proto"
+
+// WithTrustedProxies returns a ServeMuxOption which makes AnnotateContext take the client information
+// from the "Forwarded" (RFC 7239) and "X-Forwarded-*" headers only when they are added by trusted proxies.
+//
+// "cidrs" are the networks of the trusted proxies in CIDR notation, e.g. "10.0.0.0/8", or single IP addresses.
+// It panics if any of them is invalid. Multiple WithTrustedProxies options add up.
+//
+// AnnotateContext walks the hops from RemoteAddr towards the client while the hops are trusted proxies,
+// and forwards the following metadata to the gRPC server:
+//   - "x-forwarded-for": the client IP followed by the IPs of the trusted proxies which the request went through.
+//   - "x-forwarded-proto": the scheme which the client used, "http" or "https".
+//   - "x-forwarded-host": the host which the client requested.
+//
+// Entries added by the client or by untrusted proxies are discarded.
+// "Forwarded" takes precedence over "X-Forwarded-For", "X-Forwarded-Proto" and "X-Forwarded-Host" when both are present.
+//
+// Without this option, AnnotateContext appends RemoteAddr to "X-Forwarded-For" and passes "X-Forwarded-Host" through as they are.
+func WithTrustedProxies(cidrs ...string) ServeMuxOption {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		if !strings.Contains(c, "/") {
+			ip := net.ParseIP(c)
+			if ip == nil {
+				panic("runtime: invalid trusted proxy: " + c)
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic("runtime: invalid trusted proxy: " + err.Error())
+		}
+		nets = append(nets, n)
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.trustedProxies = append(serveMux.trustedProxies, nets...)
+	}
+}
+
+func (s *ServeMux) isTrustedProxy(ip net.IP) bool {
+	for _, n := range s.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedElement is a hop described by a "Forwarded" element or by "X-Forwarded-*" headers.
+type forwardedElement struct {
+	// forIP is the IP of the client of the proxy which added the element, or nil if it is unknown or obfuscated.
+	forIP net.IP
+	proto string
+	host  string
+}
+
+// forwardedPairs returns the metadata pairs which describe the client of "req" as seen through the trusted proxies.
+func (s *ServeMux) forwardedPairs(ctx context.Context, req *http.Request) []string {
+	proto := "http"
+	if req.TLS != nil {
+		proto = "https"
+	}
+	host := req.Host
+
+	remoteIP := parseForwardedIP(req.RemoteAddr)
+	if remoteIP == nil {
+		if req.RemoteAddr != "" {
+			s.Logger().Warningf(ctx, "invalid remote addr: %s", req.RemoteAddr)
+		}
+		return forwardedMetadata(nil, proto, host)
+	}
+
+	chain := []net.IP{remoteIP}
+	if s.isTrustedProxy(remoteIP) {
+		var elems []forwardedElement
+		if vals := req.Header[forwarded]; len(vals) > 0 {
+			elems = parseForwarded(vals)
+		} else {
+			elems = parseXForwarded(req.Header)
+		}
+		for i := len(elems) - 1; i >= 0; i-- {
+			e := elems[i]
+			if e.proto != "" {
+				proto = e.proto
+			}
+			if e.host != "" {
+				host = e.host
+			}
+			if e.forIP == nil {
+				break
+			}
+			chain = append(chain, e.forIP)
+			if !s.isTrustedProxy(e.forIP) {
+				break
+			}
+		}
+	}
+
+	// The client comes first as in "X-Forwarded-For".
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return forwardedMetadata(chain, proto, host)
+}
+
+func forwardedMetadata(chain []net.IP, proto, host string) []string {
+	var pairs []string
+	if len(chain) > 0 {
+		ips := make([]string, len(chain))
+		for i, ip := range chain {
+			ips[i] = ip.String()
+		}
+		pairs = append(pairs, strings.ToLower(xForwardedFor), strings.Join(ips, ", "))
+	}
+	pairs = append(pairs, strings.ToLower(xForwardedProto), proto)
+	if host != "" {
+		pairs = append(pairs, strings.ToLower(xForwardedHost), host)
+	}
+	return pairs
+}
+
+// parseForwarded parses the values of "Forwarded" headers.
+// It returns nil if they are malformed.
+func parseForwarded(vals []string) []forwardedElement {
+	var elems []forwardedElement
+	for _, val := range vals {
+		for _, elem := range splitQuoted(val, ',') {
+			var e forwardedElement
+			for _, pair := range splitQuoted(elem, ';') {
+				pair = strings.TrimSpace(pair)
+				if pair == "" {
+					continue
+				}
+				eq := strings.IndexByte(pair, '=')
+				if eq <= 0 {
+					return nil
+				}
+				name, value := strings.ToLower(strings.TrimSpace(pair[:eq])), strings.TrimSpace(pair[eq+1:])
+				if strings.HasPrefix(value, `"`) {
+					if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+						return nil
+					}
+					value = unquoteForwarded(value[1 : len(value)-1])
+				}
+				switch name {
+				case "for":
+					e.forIP = parseForwardedIP(value)
+				case "proto":
+					e.proto = normalizeForwardedProto(value)
+				case "host":
+					e.host = value
+				}
+			}
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// parseXForwarded parses "X-Forwarded-For", "X-Forwarded-Proto" and "X-Forwarded-Host".
+// The last values of "X-Forwarded-Proto" and "X-Forwarded-Host" are attributed to the nearest proxy.
+func parseXForwarded(h http.Header) []forwardedElement {
+	var elems []forwardedElement
+	for _, val := range h[xForwardedFor] {
+		for _, f := range strings.Split(val, ",") {
+			elems = append(elems, forwardedElement{forIP: parseForwardedIP(strings.TrimSpace(f))})
+		}
+	}
+	if len(elems) == 0 {
+		elems = append(elems, forwardedElement{})
+	}
+	last := &elems[len(elems)-1]
+	if vals := h[xForwardedProto]; len(vals) > 0 {
+		last.proto = normalizeForwardedProto(lastListElement(vals))
+	}
+	if vals := h[xForwardedHost]; len(vals) > 0 {
+		last.host = lastListElement(vals)
+	}
+	return elems
+}
+
+// parseForwardedIP parses a node of "Forwarded" or "X-Forwarded-For", or a RemoteAddr.
+// It returns nil if the node is not an IP address, e.g. "unknown" or an obfuscated identifier.
+func parseForwardedIP(node string) net.IP {
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	node = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	if i := strings.IndexByte(node, '%'); i >= 0 {
+		node = node[:i]
+	}
+	return net.ParseIP(node)
+}
+
+func normalizeForwardedProto(proto string) string {
+	switch p := strings.ToLower(proto); p {
+	case "http", "https":
+		return p
+	}
+	return ""
+}
+
+func lastListElement(vals []string) string {
+	elems := strings.Split(vals[len(vals)-1], ",")
+	return strings.TrimSpace(elems[len(elems)-1])
+}
+
+// unquoteForwarded removes the escapes in the content of a quoted-string.
+func unquoteForwarded(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// splitQuoted splits "s" by "sep" outside quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts   []string
+		quoted  bool
+		escaped bool
+		start   int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,148 @@
+package runtime_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAnnotateContextWithTrustedProxies(t *testing.T) {
+	for _, spec := range []struct {
+		name       string
+		remoteAddr string
+		tls        bool
+		header     http.Header
+		wantFor    []string
+		wantProto  []string
+		wantHost   []string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "192.0.2.1:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"198.51.100.1"},
+				"X-Forwarded-Host":  {"spoofed.example.com"},
+				"X-Forwarded-Proto": {"https"},
+				"Forwarded":         {"for=198.51.100.1;proto=https"},
+			},
+			wantFor:   []string{"192.0.2.1"},
+			wantProto: []string{"http"},
+			wantHost:  []string{"example.com"},
+		},
+		{
+			name:       "direct client over TLS",
+			remoteAddr: "192.0.2.1:1234",
+			tls:        true,
+			wantFor:    []string{"192.0.2.1"},
+			wantProto:  []string{"https"},
+			wantHost:   []string{"example.com"},
+		},
+		{
+			name:       "X-Forwarded headers from a trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"192.0.2.1"},
+				"X-Forwarded-Host":  {"api.example.com"},
+				"X-Forwarded-Proto": {"HTTPS"},
+			},
+			wantFor:   []string{"192.0.2.1, 10.0.0.1"},
+			wantProto: []string{"https"},
+			wantHost:  []string{"api.example.com"},
+		},
+		{
+			name:       "spoofed X-Forwarded-For behind a trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"X-Forwarded-For": {"203.0.113.1, 192.0.2.1, 10.0.0.2"},
+			},
+			wantFor:   []string{"192.0.2.1, 10.0.0.2, 10.0.0.1"},
+			wantProto: []string{"http"},
+			wantHost:  []string{"example.com"},
+		},
+		{
+			name:       "X-Forwarded headers from a trusted IPv6 proxy",
+			remoteAddr: "[2001:db8::2]:1234",
+			header: http.Header{
+				"X-Forwarded-For": {"192.0.2.1"},
+			},
+			wantFor:   []string{"192.0.2.1, 2001:db8::2"},
+			wantProto: []string{"http"},
+			wantHost:  []string{"example.com"},
+		},
+		{
+			name:       "Forwarded from trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"Forwarded":       {`for=203.0.113.1;proto=http, for="[2001:db8::1]:4711";proto=https;host=api.example.com`, "for=10.0.0.2"},
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			wantFor:   []string{"2001:db8::1, 10.0.0.2, 10.0.0.1"},
+			wantProto: []string{"https"},
+			wantHost:  []string{"api.example.com"},
+		},
+		{
+			name:       "unknown client",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"Forwarded": {"for=unknown;proto=https"},
+			},
+			wantFor:   []string{"10.0.0.1"},
+			wantProto: []string{"https"},
+			wantHost:  []string{"example.com"},
+		},
+		{
+			name:       "malformed Forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"Forwarded": {`for="192.0.2.1`},
+			},
+			wantFor:   []string{"10.0.0.1"},
+			wantProto: []string{"http"},
+			wantHost:  []string{"example.com"},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://example.com/foo", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest failed with %v; want success", err)
+			}
+			req.RemoteAddr = spec.remoteAddr
+			if spec.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range spec.header {
+				req.Header[k] = v
+			}
+
+			mux := runtime.NewServeMux(runtime.WithTrustedProxies("10.0.0.0/8"), runtime.WithTrustedProxies("2001:db8::2"))
+			ctx, err := runtime.AnnotateContext(context.Background(), mux, req)
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+			}
+			md, _ := metadata.FromOutgoingContext(ctx)
+			if got, want := md["x-forwarded-for"], spec.wantFor; !reflect.DeepEqual(got, want) {
+				t.Errorf(`md["x-forwarded-for"] = %q; want %q`, got, want)
+			}
+			if got, want := md["x-forwarded-proto"], spec.wantProto; !reflect.DeepEqual(got, want) {
+				t.Errorf(`md["x-forwarded-proto"] = %q; want %q`, got, want)
+			}
+			if got, want := md["x-forwarded-host"], spec.wantHost; !reflect.DeepEqual(got, want) {
+				t.Errorf(`md["x-forwarded-host"] = %q; want %q`, got, want)
+			}
+		})
+	}
+}
+
+func TestWithTrustedProxiesInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("runtime.WithTrustedProxies(%q) did not panic; want a panic", "10.0.0.0/33")
+		}
+	}()
+	runtime.WithTrustedProxies("10.0.0.0/33")
+}
@@ -5,13 +5,12 @@ import (
 	"io"
 	"net/http"
 	"net/textproto"
+	"strings"
 
 	"context"
 	"github.com/golang/protobuf/proto"
-	"github.com/golang/protobuf/ptypes/any"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime/internal"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -19,22 +18,22 @@ import (
 func ForwardResponseStream(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, req *http.Request, recv func() (proto.Message, error), opts ...func(context.Context, http.ResponseWriter, proto.Message) error) {
 	f, ok := w.(http.Flusher)
 	if !ok {
-		grpclog.Infof("Flush not supported in %T", w)
+		mux.Logger().Errorf(ctx, "Flush not supported in %T", w)
 		http.Error(w, "unexpected type of web server", http.StatusInternalServerError)
 		return
 	}
 
 	md, ok := ServerMetadataFromContext(ctx)
 	if !ok {
-		grpclog.Infof("Failed to extract ServerMetadata from context")
+		mux.Logger().Errorf(ctx, "Failed to extract ServerMetadata from context")
 		http.Error(w, "unexpected error", http.StatusInternalServerError)
 		return
 	}
-	handleForwardResponseServerMetadata(w, mux, md)
+	ForwardResponseServerMetadata(ctx, w, mux, md)
 
 	w.Header().Set("Transfer-Encoding", "chunked")
 	w.Header().Set("Content-Type", marshaler.ContentType())
-	if err := handleForwardResponseOptions(ctx, w, nil, opts); err != nil {
+	if err := handleForwardResponseOptions(ctx, mux, w, nil, opts); err != nil {
 		HTTPError(ctx, mux, marshaler, w, req, err)
 		return
 	}
@@ -46,62 +45,116 @@ func ForwardResponseStream(ctx context.Context, mux *ServeMux, marshaler Marshal
 		delimiter = []byte("\n")
 	}
 
-	var wroteHeader bool
+	sel := fieldsSelectorFromRequest(mux, req)
+	var wroteHeader, isHTTPBodyStream bool
 	for {
 		resp, err := recv()
 		if err == io.EOF {
 			return
 		}
 		if err != nil {
-			handleForwardResponseStreamError(wroteHeader, marshaler, w, err)
+			if isHTTPBodyStream {
+				// An error chunk would corrupt the raw body which has already been sent.
+				mux.Logger().Warningf(ctx, "Failed to receive a chunk of google.api.HttpBody: %v", err)
+				return
+			}
+			handleForwardResponseStreamError(ctx, wroteHeader, mux, marshaler, w, err)
 			return
 		}
-		if err := handleForwardResponseOptions(ctx, w, resp, opts); err != nil {
-			handleForwardResponseStreamError(wroteHeader, marshaler, w, err)
+		streamMessageReceived(ctx, req)
+		if err := handleForwardResponseOptions(ctx, mux, w, resp, opts); err != nil {
+			handleForwardResponseStreamError(ctx, wroteHeader, mux, marshaler, w, err)
 			return
 		}
 
-		buf, err := marshaler.Marshal(streamChunk(resp, nil))
+		if body, ok := responseHTTPBody(resp); ok {
+			// Stream google.api.HttpBody as a raw download without the result wrapper nor delimiters.
+			if !wroteHeader {
+				w.Header().Set("Content-Type", body.GetContentType())
+			}
+			if _, err = w.Write(body.GetData()); err != nil {
+				mux.Logger().Infof(ctx, "Failed to send response chunk: %v", err)
+				return
+			}
+			wroteHeader, isHTTPBodyStream = true, true
+			f.Flush()
+			continue
+		}
+
+		if sel != nil {
+			resp = proto.Clone(resp)
+			applyFieldsSelector(resp, sel)
+		}
+		buf, err := marshaler.Marshal(streamChunk(ctx, mux, resp, nil))
 		if err != nil {
-			grpclog.Infof("Failed to marshal response chunk: %v", err)
-			handleForwardResponseStreamError(wroteHeader, marshaler, w, err)
+			mux.Logger().Errorf(ctx, "Failed to marshal response chunk: %v", err)
+			handleForwardResponseStreamError(ctx, wroteHeader, mux, marshaler, w, err)
 			return
 		}
 		if _, err = w.Write(buf); err != nil {
-			grpclog.Infof("Failed to send response chunk: %v", err)
+			mux.Logger().Infof(ctx, "Failed to send response chunk: %v", err)
 			return
 		}
 		wroteHeader = true
 		if _, err = w.Write(delimiter); err != nil {
-			grpclog.Infof("Failed to send delimiter chunk: %v", err)
+			mux.Logger().Infof(ctx, "Failed to send delimiter chunk: %v", err)
 			return
 		}
 		f.Flush()
 	}
 }
 
-func handleForwardResponseServerMetadata(w http.ResponseWriter, mux *ServeMux, md ServerMetadata) {
+// ForwardResponseServerMetadata adds the header metadata in "md" to the header of "w".
+// The keys are mapped by the outgoing header matcher of "mux".
+// The values of binary ("-bin") keys are base64-encoded as gRPC does on HTTP/2.
+// It is intended to be used by implementations of HTTPError.
+func ForwardResponseServerMetadata(ctx context.Context, w http.ResponseWriter, mux *ServeMux, md ServerMetadata) {
 	for k, vs := range md.HeaderMD {
+		if isReservedResponseMetadata(k) {
+			continue
+		}
 		if h, ok := mux.outgoingHeaderMatcher(k); ok {
 			for _, v := range vs {
-				w.Header().Add(h, v)
+				w.Header().Add(h, outgoingHeaderValue(k, v))
 			}
 		}
 	}
+	forwardResponseCookies(ctx, w, mux, md)
+}
+
+// trailerHeaderKey returns the name of the HTTP trailer to which the trailer metadata "key" is mapped.
+// The keys are filtered by the outgoing header matcher of "mux" in the same way as the header metadata,
+// and MetadataHeaderPrefix of the mapped names is replaced with MetadataTrailerPrefix.
+func trailerHeaderKey(mux *ServeMux, key string) (string, bool) {
+	h, ok := mux.outgoingHeaderMatcher(key)
+	if !ok {
+		return "", false
+	}
+	h = strings.TrimPrefix(textproto.CanonicalMIMEHeaderKey(h), MetadataHeaderPrefix)
+	return textproto.CanonicalMIMEHeaderKey(MetadataTrailerPrefix + h), true
 }
 
-func handleForwardResponseTrailerHeader(w http.ResponseWriter, md ServerMetadata) {
+// ForwardResponseTrailerHeader announces the trailer metadata in "md" with the "Trailer" header of "w".
+// It must be called before the header is written.
+func ForwardResponseTrailerHeader(w http.ResponseWriter, mux *ServeMux, md ServerMetadata) {
 	for k := range md.TrailerMD {
-		tKey := textproto.CanonicalMIMEHeaderKey(fmt.Sprintf("%s%s", MetadataTrailerPrefix, k))
-		w.Header().Add("Trailer", tKey)
+		if tKey, ok := trailerHeaderKey(mux, k); ok {
+			w.Header().Add("Trailer", tKey)
+		}
 	}
 }
 
-func handleForwardResponseTrailer(w http.ResponseWriter, md ServerMetadata) {
+// ForwardResponseTrailer adds the trailer metadata in "md" to "w".
+// The keys and the values are mapped in the same way as ForwardResponseTrailerHeader and ForwardResponseServerMetadata.
+// It must be called after the body is written.
+func ForwardResponseTrailer(w http.ResponseWriter, mux *ServeMux, md ServerMetadata) {
 	for k, vs := range md.TrailerMD {
-		tKey := fmt.Sprintf("%s%s", MetadataTrailerPrefix, k)
+		tKey, ok := trailerHeaderKey(mux, k)
+		if !ok {
+			continue
+		}
 		for _, v := range vs {
-			w.Header().Add(tKey, v)
+			w.Header().Add(tKey, outgoingHeaderValue(k, v))
 		}
 	}
 }
@@ -116,91 +169,221 @@ type responseBody interface {
 func ForwardResponseMessage(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, req *http.Request, resp proto.Message, opts ...func(context.Context, http.ResponseWriter, proto.Message) error) {
 	md, ok := ServerMetadataFromContext(ctx)
 	if !ok {
-		grpclog.Infof("Failed to extract ServerMetadata from context")
+		mux.Logger().Errorf(ctx, "Failed to extract ServerMetadata from context")
 	}
 
-	handleForwardResponseServerMetadata(w, mux, md)
-	handleForwardResponseTrailerHeader(w, md)
-	w.Header().Set("Content-Type", marshaler.ContentType())
-	if err := handleForwardResponseOptions(ctx, w, resp, opts); err != nil {
+	ForwardResponseServerMetadata(ctx, w, mux, md)
+	ForwardResponseTrailerHeader(w, mux, md)
+	body, isHTTPBody := responseHTTPBody(resp)
+	if isHTTPBody {
+		w.Header().Set("Content-Type", body.GetContentType())
+	} else {
+		w.Header().Set("Content-Type", marshaler.ContentType())
+	}
+	if err := handleForwardResponseOptions(ctx, mux, w, resp, opts); err != nil {
 		HTTPError(ctx, mux, marshaler, w, req, err)
 		return
 	}
+
+	code := responseStatusFromMetadata(ctx, mux, md)
+	forwardResponseLocation(w, md)
+	if code != 0 && !bodyAllowedForStatus(code) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(code)
+		ForwardResponseTrailer(w, mux, md)
+		return
+	}
+
+	var v interface{} = resp
+	if !isHTTPBody {
+		sel := fieldsSelectorFromRequest(mux, req)
+		if sel != nil {
+			// The gRPC server may still use "resp".
+			v = proto.Clone(resp)
+		}
+		if rb, ok := v.(responseBody); ok {
+			v = rb.XXX_ResponseBody()
+		}
+		applyFieldsSelector(v, sel)
+	}
+
 	var buf []byte
-	var err error
-	if rb, ok := resp.(responseBody); ok {
-		buf, err = marshaler.Marshal(rb.XXX_ResponseBody())
-	} else {
-		buf, err = marshaler.Marshal(resp)
+	buffered := isHTTPBody
+	if etagApplies(mux, req) {
+		var (
+			etag string
+			err  error
+		)
+		etag, buf, err = responseETag(ctx, mux, resp, func() ([]byte, error) {
+			buffered = true
+			if isHTTPBody {
+				return body.GetData(), nil
+			}
+			return marshaler.Marshal(v)
+		})
+		if err != nil {
+			mux.Logger().Errorf(ctx, "Marshal error: %v", err)
+			HTTPError(ctx, mux, marshaler, w, req, err)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+			if inm := req.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+				w.Header().Del("Content-Type")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
 	}
+
+	if isHTTPBody {
+		buf = body.GetData()
+	}
+	if !buffered && !encodesAsMarshaled(marshaler) {
+		// The Encoder may add what Marshal does not, e.g. json.Encoder terminates each value with a newline.
+		var err error
+		if buf, err = marshaler.Marshal(v); err != nil {
+			mux.Logger().Errorf(ctx, "Marshal error: %v", err)
+			HTTPError(ctx, mux, marshaler, w, req, err)
+			return
+		}
+		buffered = true
+	}
+	if buffered {
+		// The raw google.api.HttpBody or the marshaled response.
+		if code != 0 {
+			w.WriteHeader(code)
+		}
+		if _, err := w.Write(buf); err != nil {
+			mux.Logger().Infof(ctx, "Failed to write response: %v", err)
+		}
+		ForwardResponseTrailer(w, mux, md)
+		return
+	}
+
+	// Encode the response straight into "w" so that large responses are not buffered as a whole.
+	cw := &countingWriter{w: w, status: code}
+	err := marshaler.NewEncoder(cw).Encode(v)
 	if err != nil {
-		grpclog.Infof("Marshal error: %v", err)
-		HTTPError(ctx, mux, marshaler, w, req, err)
+		mux.Logger().Errorf(ctx, "Marshal error: %v", err)
+		if cw.n == 0 {
+			HTTPError(ctx, mux, marshaler, w, req, err)
+		}
+		// Otherwise the header and a part of the body have already been sent.
+		// There is no way to notify the error to the client.
 		return
 	}
+	if cw.n == 0 && code != 0 {
+		// The response is encoded into an empty body, which has not sent the header.
+		w.WriteHeader(code)
+	}
+
+	ForwardResponseTrailer(w, mux, md)
+}
 
-	if _, err = w.Write(buf); err != nil {
-		grpclog.Infof("Failed to write response: %v", err)
+// encodesAsMarshaled returns true if the Encoder of "marshaler" writes a single message exactly as Marshal returns it,
+// so that the response can be encoded straight into the http.ResponseWriter.
+func encodesAsMarshaled(marshaler Marshaler) bool {
+	switch marshaler.(type) {
+	case *JSONPb, *ProtoMarshaller, *ProtoText, *YAMLPb:
+		return true
 	}
+	return false
+}
 
-	handleForwardResponseTrailer(w, md)
+// countingWriter counts the number of bytes written into the underlying http.ResponseWriter.
+// It drops empty writes so that they do not make http.ResponseWriter send the header.
+// If status is not zero, it is sent with the header on the first write.
+type countingWriter struct {
+	w      http.ResponseWriter
+	status int
+	n      int64
 }
 
-func handleForwardResponseOptions(ctx context.Context, w http.ResponseWriter, resp proto.Message, opts []func(context.Context, http.ResponseWriter, proto.Message) error) error {
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if w.n == 0 && w.status != 0 {
+		w.w.WriteHeader(w.status)
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func handleForwardResponseOptions(ctx context.Context, mux *ServeMux, w http.ResponseWriter, resp proto.Message, opts []func(context.Context, http.ResponseWriter, proto.Message) error) error {
 	if len(opts) == 0 {
 		return nil
 	}
 	for _, opt := range opts {
 		if err := opt(ctx, w, resp); err != nil {
-			grpclog.Infof("Error handling ForwardResponseOptions: %v", err)
+			mux.Logger().Infof(ctx, "Error handling ForwardResponseOptions: %v", err)
 			return err
 		}
 	}
 	return nil
 }
 
-func handleForwardResponseStreamError(wroteHeader bool, marshaler Marshaler, w http.ResponseWriter, err error) {
-	buf, merr := marshaler.Marshal(streamChunk(nil, err))
+// StreamErrorHandlerFunc converts an error in a response stream into the message to emit.
+// The message is emitted as the "error" member of the chunk.
+// The returned HTTP status is used only if the error occurs before the header is sent.
+type StreamErrorHandlerFunc func(ctx context.Context, mux *ServeMux, s *status.Status) (msg proto.Message, httpStatus int)
+
+var _ StreamErrorHandlerFunc = DefaultStreamErrorHandler
+
+// DefaultStreamErrorHandler is the default implementation of StreamErrorHandlerFunc.
+// It returns a message which contains the gRPC code, the HTTP status code mapped by ServeMux.HTTPStatusFromCode,
+// the message and the details of "s".
+func DefaultStreamErrorHandler(_ context.Context, mux *ServeMux, s *status.Status) (proto.Message, int) {
+	httpCode := mux.HTTPStatusFromCode(s.Code())
+	return &internal.StreamError{
+		GrpcCode:   int32(s.Code()),
+		HttpCode:   int32(httpCode),
+		Message:    s.Message(),
+		HttpStatus: http.StatusText(httpCode),
+		Details:    s.Proto().GetDetails(),
+	}, httpCode
+}
+
+func handleForwardResponseStreamError(ctx context.Context, wroteHeader bool, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, err error) {
+	chunk, httpStatus := streamErrorChunk(ctx, mux, err)
+	buf, merr := marshaler.Marshal(chunk)
 	if merr != nil {
-		grpclog.Infof("Failed to marshal an error: %v", merr)
+		mux.Logger().Errorf(ctx, "Failed to marshal an error: %v", merr)
 		return
 	}
 	if !wroteHeader {
-		s, ok := status.FromError(err)
-		if !ok {
-			s = status.New(codes.Unknown, err.Error())
-		}
-		w.WriteHeader(HTTPStatusFromCode(s.Code()))
+		w.WriteHeader(httpStatus)
 	}
 	if _, werr := w.Write(buf); werr != nil {
-		grpclog.Infof("Failed to notify error to client: %v", werr)
+		mux.Logger().Infof(ctx, "Failed to notify error to client: %v", werr)
 		return
 	}
 }
 
-func streamChunk(result proto.Message, err error) map[string]proto.Message {
+func streamChunk(ctx context.Context, mux *ServeMux, result proto.Message, err error) map[string]proto.Message {
 	if err != nil {
-		grpcCode := codes.Unknown
-		grpcMessage := err.Error()
-		var grpcDetails []*any.Any
-		if s, ok := status.FromError(err); ok {
-			grpcCode = s.Code()
-			grpcMessage = s.Message()
-			grpcDetails = s.Proto().GetDetails()
-		}
-		httpCode := HTTPStatusFromCode(grpcCode)
-		return map[string]proto.Message{
-			"error": &internal.StreamError{
-				GrpcCode:   int32(grpcCode),
-				HttpCode:   int32(httpCode),
-				Message:    grpcMessage,
-				HttpStatus: http.StatusText(httpCode),
-				Details:    grpcDetails,
-			},
-		}
+		chunk, _ := streamErrorChunk(ctx, mux, err)
+		return chunk
 	}
 	if result == nil {
-		return streamChunk(nil, fmt.Errorf("empty response"))
+		return streamChunk(ctx, mux, nil, fmt.Errorf("empty response"))
 	}
 	return map[string]proto.Message{"result": result}
 }
+
+func streamErrorChunk(ctx context.Context, mux *ServeMux, err error) (map[string]proto.Message, int) {
+	s, ok := status.FromError(err)
+	if !ok {
+		s = status.New(codes.Unknown, err.Error())
+	}
+	s = statusWithRequestID(ctx, s)
+	rpcCallFromContext(ctx, nil).setStatus(s)
+	handler := DefaultStreamErrorHandler
+	if mux != nil && mux.streamErrorHandler != nil {
+		handler = mux.streamErrorHandler
+	}
+	msg, httpStatus := handler(ctx, mux, s)
+	return map[string]proto.Message{"error": msg}, httpStatus
+}
@@ -1,10 +1,13 @@
 package runtime_test
 
 import (
+	"encoding/base64"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"context"
@@ -14,6 +17,7 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/runtime/internal"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -128,6 +132,88 @@ func TestForwardResponseStream(t *testing.T) {
 	}
 }
 
+func TestForwardResponseStreamWithStatusMapping(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		msgs       []proto.Message
+		statusCode int
+	}{{
+		name:       "error",
+		statusCode: http.StatusRequestedRangeNotSatisfiable,
+	}, {
+		name:       "stream_error",
+		msgs:       []proto.Message{&pb.SimpleMessage{Id: "One"}},
+		statusCode: http.StatusOK,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := tt.msgs
+			recv := func() (proto.Message, error) {
+				if len(msgs) == 0 {
+					return nil, grpc.Errorf(codes.OutOfRange, "out of range")
+				}
+				msg := msgs[0]
+				msgs = msgs[1:]
+				return msg, nil
+			}
+			mux := runtime.NewServeMux(runtime.WithStatusMapping(codes.OutOfRange, http.StatusRequestedRangeNotSatisfiable))
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			resp := httptest.NewRecorder()
+
+			runtime.ForwardResponseStream(ctx, mux, &runtime.JSONBuiltin{}, resp, req, recv)
+
+			if got, want := resp.Code, tt.statusCode; got != want {
+				t.Errorf("resp.Code = %d; want %d", got, want)
+			}
+			if got, want := resp.Body.String(), `"http_code":416`; !strings.Contains(got, want) {
+				t.Errorf("resp.Body = %q; want to contain %q", got, want)
+			}
+		})
+	}
+}
+
+func TestForwardResponseStreamWithStreamErrorHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		msgs       []proto.Message
+		statusCode int
+	}{{
+		name:       "error",
+		statusCode: http.StatusTeapot,
+	}, {
+		name:       "stream_error",
+		msgs:       []proto.Message{&pb.SimpleMessage{Id: "One"}},
+		statusCode: http.StatusOK,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := tt.msgs
+			recv := func() (proto.Message, error) {
+				if len(msgs) == 0 {
+					return nil, grpc.Errorf(codes.OutOfRange, "out of range")
+				}
+				msg := msgs[0]
+				msgs = msgs[1:]
+				return msg, nil
+			}
+			mux := runtime.NewServeMux(runtime.WithStreamErrorHandler(func(_ context.Context, _ *runtime.ServeMux, s *status.Status) (proto.Message, int) {
+				return s.Proto(), http.StatusTeapot
+			}))
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			resp := httptest.NewRecorder()
+
+			runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, resp, req, recv)
+
+			if got, want := resp.Code, tt.statusCode; got != want {
+				t.Errorf("resp.Code = %d; want %d", got, want)
+			}
+			if got, want := resp.Body.String(), `{"error":{"code":11,"message":"out of range"}}`; !strings.HasSuffix(got, want) {
+				t.Errorf("resp.Body = %q; want to end with %q", got, want)
+			}
+		})
+	}
+}
+
 // A custom marshaler implementation, that doesn't implement the delimited interface
 type CustomMarshaler struct {
 	m *runtime.JSONPb
@@ -226,3 +312,151 @@ func TestForwardResponseStreamCustomMarshaler(t *testing.T) {
 		})
 	}
 }
+
+// failingMarshaler is a marshaler which fails to marshal *pb.SimpleMessage.
+type failingMarshaler struct {
+	runtime.JSONBuiltin
+}
+
+func (m *failingMarshaler) Marshal(v interface{}) ([]byte, error) {
+	if _, ok := v.(*pb.SimpleMessage); ok {
+		return nil, fmt.Errorf("failed to encode %v", v)
+	}
+	return m.JSONBuiltin.Marshal(v)
+}
+
+func TestForwardResponseMessage(t *testing.T) {
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	for _, tt := range []struct {
+		name       string
+		marshaler  runtime.Marshaler
+		statusCode int
+		body       string
+	}{{
+		name:       "encoding",
+		marshaler:  &runtime.JSONPb{OrigName: true},
+		statusCode: http.StatusOK,
+		body:       `{"id":"One"}`,
+	}, {
+		// json.Encoder would terminate the body with a newline.
+		name:       "marshaling",
+		marshaler:  &runtime.JSONBuiltin{},
+		statusCode: http.StatusOK,
+		body:       `{"id":"One","Code":null,"Ext":null}`,
+	}, {
+		name:       "failure",
+		marshaler:  &failingMarshaler{},
+		statusCode: http.StatusInternalServerError,
+		body:       "failed to encode",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			resp := httptest.NewRecorder()
+
+			runtime.ForwardResponseMessage(ctx, runtime.NewServeMux(), tt.marshaler, resp, req, &pb.SimpleMessage{Id: "One"})
+
+			if got, want := resp.Code, tt.statusCode; got != want {
+				t.Errorf("resp.Code = %d; want %d", got, want)
+			}
+			if got, want := resp.Body.String(), tt.body; !strings.Contains(got, want) {
+				t.Errorf("resp.Body = %q; want to contain %q", got, want)
+			}
+			if tt.statusCode == http.StatusOK {
+				if got, want := resp.Body.String(), tt.body; got != want {
+					t.Errorf("resp.Body = %q; want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+func BenchmarkForwardResponseMessage(b *testing.B) {
+	msg := &pb.ABitOfEverything{
+		RepeatedStringValue: make([]string, 100000),
+	}
+	for i := range msg.RepeatedStringValue {
+		msg.RepeatedStringValue[i] = strings.Repeat("x", 100)
+	}
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	mux := runtime.NewServeMux()
+	marshaler := &runtime.JSONPb{}
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+
+	b.Run("Marshal", func(b *testing.B) {
+		// The former implementation, which buffers the whole response before writing.
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w := &discardResponseWriter{header: make(http.Header)}
+			buf, err := marshaler.Marshal(msg)
+			if err != nil {
+				b.Fatalf("marshaler.Marshal(msg) failed with %v; want success", err)
+			}
+			w.Write(buf)
+		}
+	})
+	b.Run("ForwardResponseMessage", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w := &discardResponseWriter{header: make(http.Header)}
+			runtime.ForwardResponseMessage(ctx, mux, marshaler, w, req, msg)
+		}
+	})
+}
+
+func TestForwardResponseMetadata(t *testing.T) {
+	md := runtime.ServerMetadata{
+		HeaderMD: metadata.Pairs(
+			"foo", "bar",
+			"token-bin", "\x00\x01binary",
+		),
+		TrailerMD: metadata.Pairs(
+			"baz", "qux",
+			"hash-bin", "\xff\xfe",
+			"internal", "secret",
+		),
+	}
+	mux := runtime.NewServeMux(runtime.WithOutgoingHeaderMatcher(func(key string) (string, bool) {
+		if key == "internal" {
+			return "", false
+		}
+		return runtime.MetadataHeaderPrefix + key, true
+	}))
+
+	w := httptest.NewRecorder()
+	runtime.ForwardResponseServerMetadata(context.Background(), w, mux, md)
+	runtime.ForwardResponseTrailerHeader(w, mux, md)
+	runtime.ForwardResponseTrailer(w, mux, md)
+
+	for _, spec := range []struct {
+		key  string
+		want string
+	}{
+		{key: "Grpc-Metadata-Foo", want: "bar"},
+		{key: "Grpc-Metadata-Token-Bin", want: base64.StdEncoding.EncodeToString([]byte("\x00\x01binary"))},
+		{key: "Grpc-Trailer-Baz", want: "qux"},
+		{key: "Grpc-Trailer-Hash-Bin", want: base64.StdEncoding.EncodeToString([]byte("\xff\xfe"))},
+		{key: "Grpc-Trailer-Internal", want: ""},
+	} {
+		if got := w.Header().Get(spec.key); got != spec.want {
+			t.Errorf("w.Header().Get(%q) = %q; want %q", spec.key, got, spec.want)
+		}
+	}
+
+	announced := make(map[string]bool)
+	for _, k := range w.Header()["Trailer"] {
+		announced[k] = true
+	}
+	for k, want := range map[string]bool{"Grpc-Trailer-Baz": true, "Grpc-Trailer-Hash-Bin": true, "Grpc-Trailer-Internal": false} {
+		if got := announced[k]; got != want {
+			t.Errorf("announced[%q] = %v; want %v", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// DecodeHTTPBody sets the body of "req" to "v" as it is.
+// "v" must be either *httpbody.HttpBody or **httpbody.HttpBody.
+// The Content-Type of "req" goes to the content_type field and the body goes to the data field.
+//
+// The generated code uses this function instead of Marshalers when the request body is mapped to google.api.HttpBody.
+func DecodeHTTPBody(req *http.Request, v interface{}) error {
+	var body *httpbody.HttpBody
+	switch v := v.(type) {
+	case *httpbody.HttpBody:
+		body = v
+	case **httpbody.HttpBody:
+		if *v == nil {
+			*v = new(httpbody.HttpBody)
+		}
+		body = *v
+	default:
+		return fmt.Errorf("unable to decode request body into %T", v)
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	body.ContentType = req.Header.Get("Content-Type")
+	body.Data = data
+	return nil
+}
+
+// responseHTTPBody returns the google.api.HttpBody to be written as the response for "resp", if any.
+func responseHTTPBody(resp interface{}) (*httpbody.HttpBody, bool) {
+	if rb, ok := resp.(responseBody); ok {
+		resp = rb.XXX_ResponseBody()
+	}
+	body, ok := resp.(*httpbody.HttpBody)
+	return body, ok
+}
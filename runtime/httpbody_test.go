@@ -0,0 +1,111 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+func TestDecodeHTTPBody(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("POST", "http://example.com/upload", strings.NewReader("a,b,c\n"))
+		r.Header.Set("Content-Type", "text/csv")
+		return r
+	}
+	want := &httpbody.HttpBody{ContentType: "text/csv", Data: []byte("a,b,c\n")}
+
+	var body httpbody.HttpBody
+	if err := runtime.DecodeHTTPBody(newRequest(), &body); err != nil {
+		t.Fatalf("runtime.DecodeHTTPBody(r, &body) failed with %v; want success", err)
+	}
+	if !proto.Equal(&body, want) {
+		t.Errorf("body = %v; want %v", &body, want)
+	}
+
+	var field *httpbody.HttpBody
+	if err := runtime.DecodeHTTPBody(newRequest(), &field); err != nil {
+		t.Fatalf("runtime.DecodeHTTPBody(r, &field) failed with %v; want success", err)
+	}
+	if !proto.Equal(field, want) {
+		t.Errorf("field = %v; want %v", field, want)
+	}
+
+	var s string
+	if err := runtime.DecodeHTTPBody(newRequest(), &s); err == nil {
+		t.Errorf("runtime.DecodeHTTPBody(r, &s) succeeded; want failure")
+	}
+}
+
+type httpBodyResponse struct {
+	httpbody.HttpBody
+}
+
+func (r *httpBodyResponse) XXX_ResponseBody() interface{} {
+	return &r.HttpBody
+}
+
+func TestForwardResponseMessageHTTPBody(t *testing.T) {
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	for _, resp := range []proto.Message{
+		&httpbody.HttpBody{ContentType: "image/png", Data: []byte("\x89PNG")},
+		&httpBodyResponse{httpbody.HttpBody{ContentType: "image/png", Data: []byte("\x89PNG")}},
+	} {
+		req := httptest.NewRequest("GET", "http://example.com/image", nil)
+		w := httptest.NewRecorder()
+		runtime.ForwardResponseMessage(ctx, runtime.NewServeMux(), &runtime.JSONPb{}, w, req, resp)
+
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Errorf("w.Code = %d; want %d", got, want)
+		}
+		if got, want := w.Header().Get("Content-Type"), "image/png"; got != want {
+			t.Errorf(`w.Header().Get("Content-Type") = %q; want %q`, got, want)
+		}
+		if got, want := w.Body.String(), "\x89PNG"; got != want {
+			t.Errorf("w.Body = %q; want %q", got, want)
+		}
+	}
+}
+
+func TestForwardResponseStreamHTTPBody(t *testing.T) {
+	for _, spec := range []struct {
+		name string
+		err  error
+	}{
+		{name: "eof", err: io.EOF},
+		{name: "error", err: errors.New("broken stream")},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			chunks := []string{"a,b\n", "c,d\n"}
+			recv := func() (proto.Message, error) {
+				if len(chunks) == 0 {
+					return nil, spec.err
+				}
+				c := chunks[0]
+				chunks = chunks[1:]
+				return &httpbody.HttpBody{ContentType: "text/csv", Data: []byte(c)}, nil
+			}
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			req := httptest.NewRequest("GET", "http://example.com/export", nil)
+			w := httptest.NewRecorder()
+			runtime.ForwardResponseStream(ctx, runtime.NewServeMux(), &runtime.JSONPb{}, w, req, recv)
+
+			if got, want := w.Code, http.StatusOK; got != want {
+				t.Errorf("w.Code = %d; want %d", got, want)
+			}
+			if got, want := w.Header().Get("Content-Type"), "text/csv"; got != want {
+				t.Errorf(`w.Header().Get("Content-Type") = %q; want %q`, got, want)
+			}
+			if got, want := w.Body.String(), "a,b\nc,d\n"; got != want {
+				t.Errorf("w.Body = %q; want %q", got, want)
+			}
+		})
+	}
+}
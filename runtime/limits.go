@@ -0,0 +1,299 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The keys by which a Limit can be applied separately.
+const (
+	// LimitKeyClientIP applies a Limit to each client IP address.
+	// The address is taken from the forwarding headers only if WithTrustedProxies is given.
+	LimitKeyClientIP = "client_ip"
+	// LimitKeyMetadataPrefix followed by a metadata key, e.g. "metadata:x-api-key", applies a Limit to each value of
+	// the metadata forwarded to the gRPC server. Requests without the metadata share a Limit.
+	LimitKeyMetadataPrefix = "metadata:"
+)
+
+// inFlightRetryDelay is the delay clients are told to retry after when they are rejected by MaxInFlight.
+const inFlightRetryDelay = time.Second
+
+// Limit is an admission control limit on the requests to the RPC methods selected by Selector.
+// The requests over the limit are rejected with ResourceExhausted statuses, which have google.rpc.RetryInfo
+// in the details and are replied with Retry-After headers.
+type Limit struct {
+	// Selector selects the RPC methods in the same way as the selectors of the rules in the gRPC API configuration,
+	// e.g. "pkg.Service.Method", "pkg.Service.*" or "*" for the whole ServeMux.
+	// The RPC methods selected by a Limit share it.
+	Selector string `json:"selector"`
+	// Rate is the number of requests per second allowed in the long run with a token bucket.
+	// 0 means no rate limit.
+	Rate float64 `json:"rate,omitempty"`
+	// Burst is the size of the token bucket, i.e. the number of requests allowed at once.
+	// It defaults to Rate rounded up.
+	Burst int `json:"burst,omitempty"`
+	// MaxInFlight is the number of requests allowed to be handled at the same time.
+	// 0 means no limit.
+	MaxInFlight int `json:"max_in_flight,omitempty"`
+	// Key is empty to apply the Limit to all the requests together, LimitKeyClientIP or
+	// LimitKeyMetadataPrefix followed by a metadata key.
+	Key string `json:"key,omitempty"`
+}
+
+// WithLimits returns a ServeMuxOption which makes AnnotateContext admit requests within "limits".
+// A request must be within all the limits whose Selector selects its RPC method.
+// It panics if a limit is invalid.
+//
+// The requests are counted as in flight until their responses are written by ServeMux.
+func WithLimits(limits ...Limit) ServeMuxOption {
+	ls := make([]*limiter, 0, len(limits))
+	for _, l := range limits {
+		lim, err := newLimiter(l)
+		if err != nil {
+			panic(err)
+		}
+		ls = append(ls, lim)
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.limiters = append(serveMux.limiters, ls...)
+	}
+}
+
+// LoadLimitsFromYAML loads the limits in the "limits" section of a gRPC API configuration file,
+// which is also given to protoc-gen-grpc-gateway as grpc_api_configuration, e.g.
+//
+//	type: google.api.Service
+//	config_version: 3
+//	http:
+//	  rules:
+//	  - selector: pkg.Service.Search
+//	    get: /v1/search
+//	limits:
+//	- selector: "*"
+//	  max_in_flight: 100
+//	- selector: pkg.Service.Search
+//	  rate: 10
+//	  burst: 20
+//	  key: client_ip
+//
+// Pass the result to WithLimits.
+func LoadLimitsFromYAML(yamlFile string) ([]Limit, error) {
+	buf, err := ioutil.ReadFile(yamlFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read limits from %q: %v", yamlFile, err)
+	}
+	var config struct {
+		Limits []Limit `json:"limits"`
+	}
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse limits in %q: %v", yamlFile, err)
+	}
+	for _, l := range config.Limits {
+		if _, err := newLimiter(l); err != nil {
+			return nil, fmt.Errorf("invalid limit in %q: %v", yamlFile, err)
+		}
+	}
+	return config.Limits, nil
+}
+
+// limiter enforces a Limit.
+type limiter struct {
+	Limit
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*limiterBucket
+	// nextSweep is the number of buckets at which the idle buckets are removed.
+	nextSweep int
+}
+
+// limiterBucket is the state of a limiter for a key.
+type limiterBucket struct {
+	tokens   float64
+	last     time.Time
+	inFlight int
+}
+
+const minLimiterSweep = 1024
+
+func newLimiter(l Limit) (*limiter, error) {
+	if l.Selector == "" {
+		return nil, fmt.Errorf("selector is empty")
+	}
+	if strings.Contains(strings.TrimSuffix(l.Selector, "*"), "*") {
+		return nil, fmt.Errorf("selector %q has a wildcard which is not at the end", l.Selector)
+	}
+	if l.Rate < 0 || l.Burst < 0 || l.MaxInFlight < 0 {
+		return nil, fmt.Errorf("limit for %q is negative", l.Selector)
+	}
+	if l.Rate == 0 && l.MaxInFlight == 0 {
+		return nil, fmt.Errorf("limit for %q has neither rate nor max_in_flight", l.Selector)
+	}
+	if l.Key != "" && l.Key != LimitKeyClientIP && (!strings.HasPrefix(l.Key, LimitKeyMetadataPrefix) || l.Key == LimitKeyMetadataPrefix) {
+		return nil, fmt.Errorf("unknown key of limit for %q: %q", l.Selector, l.Key)
+	}
+	lim := &limiter{
+		Limit:     l,
+		burst:     float64(l.Burst),
+		buckets:   make(map[string]*limiterBucket),
+		nextSweep: minLimiterSweep,
+	}
+	if lim.burst == 0 {
+		lim.burst = math.Max(1, math.Ceil(l.Rate))
+	}
+	lim.Key = strings.ToLower(l.Key)
+	return lim, nil
+}
+
+// selects returns true if the Selector of "l" selects the RPC method whose full name is "method", e.g. "/pkg.Service/Method".
+func (l *limiter) selects(method string) bool {
+	name := strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", -1)
+	if strings.HasSuffix(l.Selector, "*") {
+		return strings.HasPrefix(name, strings.TrimSuffix(l.Selector, "*"))
+	}
+	return name == l.Selector
+}
+
+// acquire admits a request with "key" at "now". It returns the delay to retry after if the request is rejected.
+func (l *limiter) acquire(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.nextSweep {
+			l.sweep(now)
+		}
+		b = &limiterBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	if l.Rate > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+		b.last = now
+	}
+	if l.MaxInFlight > 0 && b.inFlight >= l.MaxInFlight {
+		return inFlightRetryDelay, false
+	}
+	if l.Rate > 0 {
+		if b.tokens < 1 {
+			return time.Duration((1 - b.tokens) / l.Rate * float64(time.Second)), false
+		}
+		b.tokens--
+	}
+	b.inFlight++
+	return 0, true
+}
+
+// release ends a request acquired with "key". If "refund" is true, the request has been rejected by another limiter,
+// so its token is returned to the bucket.
+func (l *limiter) release(key string, refund bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		b.inFlight--
+		if refund && l.Rate > 0 {
+			b.tokens = math.Min(l.burst, b.tokens+1)
+		}
+	}
+}
+
+// sweep removes the buckets which are in the initial state, so that they do not pile up with keys.
+func (l *limiter) sweep(now time.Time) {
+	for k, b := range l.buckets {
+		full := l.Rate == 0 || b.tokens+now.Sub(b.last).Seconds()*l.Rate >= l.burst
+		if b.inFlight == 0 && full {
+			delete(l.buckets, k)
+		}
+	}
+	l.nextSweep = 2 * len(l.buckets)
+	if l.nextSweep < minLimiterSweep {
+		l.nextSweep = minLimiterSweep
+	}
+}
+
+// key returns the key of the request "req" with the outgoing metadata "md" for "l".
+func (l *limiter) key(mux *ServeMux, req *http.Request, md metadata.MD) string {
+	switch {
+	case l.Key == LimitKeyClientIP:
+		if mux.trustedProxies != nil {
+			if fwd := md[strings.ToLower(xForwardedFor)]; len(fwd) > 0 {
+				return strings.TrimSpace(strings.Split(fwd[0], ",")[0])
+			}
+		}
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			return req.RemoteAddr
+		}
+		return host
+	case strings.HasPrefix(l.Key, LimitKeyMetadataPrefix):
+		return strings.Join(md[strings.TrimPrefix(l.Key, LimitKeyMetadataPrefix)], ",")
+	}
+	return ""
+}
+
+// admit admits the request "req" whose outgoing metadata is "md" within the limits of "s".
+// The admitted request is counted as in flight until the response is written, or until "ctx" is done
+// if the request is not served by "s".
+func (s *ServeMux) admit(ctx context.Context, req *http.Request, md metadata.MD) (context.Context, error) {
+	if len(s.limiters) == 0 {
+		return ctx, nil
+	}
+	method, _ := RPCMethodFromContext(ctx)
+	now := time.Now()
+	var releases []func(refund bool)
+	release := func(refund bool) {
+		for _, r := range releases {
+			r(refund)
+		}
+	}
+	for _, l := range s.limiters {
+		if !l.selects(method) {
+			continue
+		}
+		l, key := l, l.key(s, req, md)
+		delay, ok := l.acquire(key, now)
+		if !ok {
+			release(true)
+			s.Logger().Debugf(ctx, "Rejected a request over the limit for %q", l.Selector)
+			return nil, limitExceededError(l.Selector, delay)
+		}
+		releases = append(releases, func(refund bool) { l.release(key, refund) })
+	}
+	if len(releases) == 0 {
+		return ctx, nil
+	}
+
+	if call := rpcCallFromContext(ctx, req); call != nil {
+		call.releases = append(call.releases, func() { release(false) })
+	} else if done := ctx.Done(); done != nil {
+		go func() {
+			<-done
+			release(false)
+		}()
+	} else {
+		release(false)
+	}
+	return ctx, nil
+}
+
+func limitExceededError(selector string, delay time.Duration) error {
+	s := status.Newf(codes.ResourceExhausted, "too many requests to %s", selector)
+	if d, err := s.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(delay)}); err == nil {
+		s = d
+	}
+	return s.Err()
+}
@@ -0,0 +1,192 @@
+package runtime_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func annotateWithLimits(t *testing.T, mux *runtime.ServeMux, method, remoteAddr string, header http.Header) error {
+	req, err := http.NewRequest("GET", "http://example.com/foo", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest failed with %v; want success", err)
+	}
+	req.RemoteAddr = remoteAddr
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	ctx := runtime.NewRPCMethodContext(context.Background(), method)
+	_, err = runtime.AnnotateContext(ctx, mux, req)
+	return err
+}
+
+func TestAnnotateContextWithRateLimits(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithLimits(
+		runtime.Limit{Selector: "pkg.Service.Search", Rate: 1, Burst: 2, Key: runtime.LimitKeyClientIP},
+		runtime.Limit{Selector: "pkg.Other.*", Rate: 1, Key: "metadata:X-Api-Key"},
+	))
+
+	for i, spec := range []struct {
+		method     string
+		remoteAddr string
+		apiKey     string
+		wantCode   codes.Code
+	}{
+		{method: "/pkg.Service/Search", remoteAddr: "192.0.2.1:1234", wantCode: codes.OK},
+		{method: "/pkg.Service/Search", remoteAddr: "192.0.2.1:1234", wantCode: codes.OK},
+		{method: "/pkg.Service/Search", remoteAddr: "192.0.2.1:1234", wantCode: codes.ResourceExhausted},
+		{method: "/pkg.Service/Search", remoteAddr: "192.0.2.2:1234", wantCode: codes.OK},
+		{method: "/pkg.Service/Get", remoteAddr: "192.0.2.1:1234", wantCode: codes.OK},
+		{method: "/pkg.Other/Get", apiKey: "a", wantCode: codes.OK},
+		{method: "/pkg.Other/List", apiKey: "a", wantCode: codes.ResourceExhausted},
+		{method: "/pkg.Other/List", apiKey: "b", wantCode: codes.OK},
+	} {
+		header := http.Header{}
+		if spec.apiKey != "" {
+			header.Set("Grpc-Metadata-X-Api-Key", spec.apiKey)
+		}
+		err := annotateWithLimits(t, mux, spec.method, spec.remoteAddr, header)
+		if got := status.Code(err); got != spec.wantCode {
+			t.Errorf("#%d: runtime.AnnotateContext(ctx, mux, req) failed with %v; want code %v", i, err, spec.wantCode)
+		}
+		if err == nil {
+			continue
+		}
+		var retry *errdetails.RetryInfo
+		for _, d := range status.Convert(err).Details() {
+			if r, ok := d.(*errdetails.RetryInfo); ok {
+				retry = r
+			}
+		}
+		if retry == nil || retry.GetRetryDelay().GetSeconds() > 1 {
+			t.Errorf("#%d: details of %v has RetryInfo %v; want a delay up to 1s", i, err, retry)
+		}
+	}
+}
+
+func TestAnnotateContextRefundsRateLimits(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithLimits(
+		runtime.Limit{Selector: "pkg.Service.*", Rate: 1, Burst: 2},
+		runtime.Limit{Selector: "pkg.Service.Search", Rate: 1, Key: "metadata:X-Api-Key"},
+	))
+	header := http.Header{"Grpc-Metadata-X-Api-Key": {"a"}}
+
+	for i, spec := range []struct {
+		method   string
+		wantCode codes.Code
+	}{
+		{method: "/pkg.Service/Search", wantCode: codes.OK},
+		// Rejected by the second limit. The token of the first limit is refunded.
+		{method: "/pkg.Service/Search", wantCode: codes.ResourceExhausted},
+		{method: "/pkg.Service/Get", wantCode: codes.OK},
+		{method: "/pkg.Service/Get", wantCode: codes.ResourceExhausted},
+	} {
+		err := annotateWithLimits(t, mux, spec.method, "192.0.2.1:1234", header)
+		if got := status.Code(err); got != spec.wantCode {
+			t.Errorf("#%d: runtime.AnnotateContext(ctx, mux, req) failed with %v; want code %v", i, err, spec.wantCode)
+		}
+	}
+}
+
+func TestServeMuxWithMaxInFlight(t *testing.T) {
+	pat, err := runtime.NewPattern(1, []int{int(utilities.OpLitPush), 0}, []string{"foo"}, "")
+	if err != nil {
+		t.Fatalf("runtime.NewPattern failed with %v; want success", err)
+	}
+	mux := runtime.NewServeMux(runtime.WithLimits(runtime.Limit{Selector: "*", MaxInFlight: 1}))
+
+	var nested *httptest.ResponseRecorder
+	mux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := runtime.NewRPCMethodContext(r.Context(), "/pkg.Service/Get")
+		ctx, err := runtime.AnnotateContext(ctx, mux, r)
+		if err != nil {
+			runtime.HTTPError(r.Context(), mux, &runtime.JSONBuiltin{}, w, r, err)
+			return
+		}
+		if nested == nil {
+			// Another request while this one is in flight.
+			nested = httptest.NewRecorder()
+			mux.ServeHTTP(nested, httptest.NewRequest("GET", "/foo", nil))
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/foo", nil))
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+	if got, want := nested.Code, http.StatusTooManyRequests; got != want {
+		t.Errorf("nested.Code = %d; want %d", got, want)
+	}
+	if got, want := nested.Header().Get("Retry-After"), strconv.Itoa(1); got != want {
+		t.Errorf(`nested.Header().Get("Retry-After") = %q; want %q`, got, want)
+	}
+
+	// The limit is released after the response.
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/foo", nil))
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+}
+
+func TestLoadLimitsFromYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "limits")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir failed with %v; want success", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "api.yaml")
+	content := `
+type: google.api.Service
+config_version: 3
+
+http:
+  rules:
+  - selector: pkg.Service.Search
+    get: /v1/search
+
+limits:
+- selector: "*"
+  max_in_flight: 100
+- selector: pkg.Service.Search
+  rate: 10
+  burst: 20
+  key: client_ip
+`
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile failed with %v; want success", err)
+	}
+
+	got, err := runtime.LoadLimitsFromYAML(file)
+	if err != nil {
+		t.Fatalf("runtime.LoadLimitsFromYAML(%q) failed with %v; want success", file, err)
+	}
+	want := []runtime.Limit{
+		{Selector: "*", MaxInFlight: 100},
+		{Selector: "pkg.Service.Search", Rate: 10, Burst: 20, Key: runtime.LimitKeyClientIP},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("runtime.LoadLimitsFromYAML(%q) = %+v; want %+v", file, got, want)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("limits:\n- selector: \"*\"\n  key: client_ip\n"), 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile failed with %v; want success", err)
+	}
+	if _, err := runtime.LoadLimitsFromYAML(file); err == nil {
+		t.Errorf("runtime.LoadLimitsFromYAML(%q) succeeded; want an error for a limit without rate nor max_in_flight", file)
+	}
+}
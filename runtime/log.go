@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/grpclog"
+)
+
+// Logger is a leveled logger used by the runtime and the generated code.
+//
+// "ctx" is the context of the request being handled, or context.Background() if there is none.
+// Implementations can extract structured fields from it, e.g. the RPC method with RPCMethodFromContext.
+type Logger interface {
+	Debugf(ctx context.Context, format string, args ...interface{})
+	Infof(ctx context.Context, format string, args ...interface{})
+	Warningf(ctx context.Context, format string, args ...interface{})
+	Errorf(ctx context.Context, format string, args ...interface{})
+}
+
+// WithLogger returns a ServeMuxOption which makes the ServeMux and the handlers registered to it log with "logger".
+// Logs go to grpclog by default.
+func WithLogger(logger Logger) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.logger = logger
+	}
+}
+
+// Logger returns the Logger of "s".
+func (s *ServeMux) Logger() Logger {
+	if s == nil || s.logger == nil {
+		return defaultLogger
+	}
+	return s.logger
+}
+
+// defaultLogger writes logs into grpclog with the RPC method as the prefix.
+var defaultLogger Logger = grpclogLogger{}
+
+type grpclogLogger struct{}
+
+func (grpclogLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	if grpclog.V(2) {
+		grpclog.Info(logMessage(ctx, format, args))
+	}
+}
+
+func (grpclogLogger) Infof(ctx context.Context, format string, args ...interface{}) {
+	grpclog.Info(logMessage(ctx, format, args))
+}
+
+func (grpclogLogger) Warningf(ctx context.Context, format string, args ...interface{}) {
+	grpclog.Warning(logMessage(ctx, format, args))
+}
+
+func (grpclogLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	grpclog.Error(logMessage(ctx, format, args))
+}
+
+func logMessage(ctx context.Context, format string, args []interface{}) string {
+	msg := fmt.Sprintf(format, args...)
+	if method, ok := RPCMethodFromContext(ctx); ok {
+		return fmt.Sprintf("[%s] %s", method, msg)
+	}
+	return msg
+}
+
+type (
+	rpcMethodKey struct{}
+	loggerKey    struct{}
+)
+
+// NewRPCMethodContext returns a new context which carries the full name of the RPC method, e.g. "/pkg.Service/Method".
+// The generated handlers call this for each request.
+func NewRPCMethodContext(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, rpcMethodKey{}, method)
+}
+
+// RPCMethodFromContext returns the full name of the RPC method in "ctx", if any.
+func RPCMethodFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	method, ok := ctx.Value(rpcMethodKey{}).(string)
+	return method, ok
+}
+
+// LoggerFromContext returns the Logger of the ServeMux which "ctx" has been annotated by with AnnotateContext.
+// It returns the default Logger if there is none.
+func LoggerFromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+			return logger
+		}
+	}
+	return defaultLogger
+}
@@ -0,0 +1,110 @@
+package runtime_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+type logEntry struct {
+	level  string
+	method string
+	msg    string
+}
+
+// recordingLogger records log entries with the RPC method in the context.
+type recordingLogger struct {
+	entries []logEntry
+}
+
+func (l *recordingLogger) log(ctx context.Context, level, format string, args []interface{}) {
+	method, _ := runtime.RPCMethodFromContext(ctx)
+	l.entries = append(l.entries, logEntry{level: level, method: method, msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *recordingLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, "debug", format, args)
+}
+
+func (l *recordingLogger) Infof(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, "info", format, args)
+}
+
+func (l *recordingLogger) Warningf(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, "warning", format, args)
+}
+
+func (l *recordingLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, "error", format, args)
+}
+
+func TestForwardResponseMessageWithLogger(t *testing.T) {
+	logger := new(recordingLogger)
+	mux := runtime.NewServeMux(runtime.WithLogger(logger))
+	ctx := runtime.NewRPCMethodContext(context.Background(), "/example.ExampleService/Example")
+	ctx = runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{})
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	w := httptest.NewRecorder()
+
+	runtime.ForwardResponseMessage(ctx, mux, &failingMarshaler{}, w, req, &pb.SimpleMessage{Id: "foo"})
+
+	if len(logger.entries) == 0 {
+		t.Fatalf("logger.entries is empty; want the marshal error")
+	}
+	got := logger.entries[0]
+	if got.level != "error" || got.method != "/example.ExampleService/Example" || !strings.Contains(got.msg, "failed to encode") {
+		t.Errorf("logger.entries[0] = %+v; want the marshal error of /example.ExampleService/Example", got)
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	logger := new(recordingLogger)
+	for _, spec := range []struct {
+		mux  *runtime.ServeMux
+		want runtime.Logger
+	}{
+		{
+			mux:  runtime.NewServeMux(runtime.WithLogger(logger)),
+			want: logger,
+		},
+		{
+			mux:  runtime.NewServeMux(),
+			want: runtime.NewServeMux().Logger(),
+		},
+	} {
+		req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		ctx, err := runtime.AnnotateContext(context.Background(), spec.mux, req)
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+		}
+		if got := runtime.LoggerFromContext(ctx); got != spec.want {
+			t.Errorf("runtime.LoggerFromContext(ctx) = %#v; want %#v", got, spec.want)
+		}
+	}
+}
+
+func TestPopulateFieldValuesFromPathLogsUnknownFields(t *testing.T) {
+	logger := new(recordingLogger)
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	ctx := runtime.NewRPCMethodContext(context.Background(), "/example.ExampleService/Example")
+	ctx, err := runtime.AnnotateContext(ctx, runtime.NewServeMux(runtime.WithLogger(logger)), req)
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+	}
+
+	msg := new(pb.SimpleMessage)
+	if err := runtime.PopulateFieldValuesFromPath(ctx, msg, []string{"unknown"}, []string{"foo"}); err != nil {
+		t.Fatalf("runtime.PopulateFieldValuesFromPath(ctx, msg, %q, %q) failed with %v; want success", "unknown", "foo", err)
+	}
+	if len(logger.entries) != 1 || logger.entries[0].method != "/example.ExampleService/Example" || !strings.Contains(logger.entries[0].msg, "unknown") {
+		t.Errorf("logger.entries = %+v; want an entry about the unknown field of /example.ExampleService/Example", logger.entries)
+	}
+}
@@ -0,0 +1,107 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+)
+
+// defaultMaxFormBodySize is the default limit of the size of form bodies.
+// It is the same as the limit of http.Request.ParseForm.
+const defaultMaxFormBodySize = int64(10 << 20)
+
+// FormMarshaler is a Marshaler which unmarshals application/x-www-form-urlencoded request bodies.
+// Form fields are mapped onto the message in the same way as PopulateQueryParameters, e.g.
+// "a.b=1&c[key]=value".
+//
+// It is intended to be registered as an inbound Marshaler with WithMarshalerOption.
+// Responses are marshaled by ResponseMarshaler.
+type FormMarshaler struct {
+	// ResponseMarshaler marshals responses.
+	// If nil, the default JSON Marshaler of ServeMux is used.
+	ResponseMarshaler Marshaler
+	// MaxBodySize limits the size of request bodies in bytes.
+	// If zero, 10MB is used.
+	MaxBodySize int64
+}
+
+func (m *FormMarshaler) responseMarshaler() Marshaler {
+	if m.ResponseMarshaler != nil {
+		return m.ResponseMarshaler
+	}
+	return defaultMarshaler
+}
+
+// ContentType returns the Content-Type of the responses.
+func (m *FormMarshaler) ContentType() string {
+	return m.responseMarshaler().ContentType()
+}
+
+// Marshal marshals "v" with ResponseMarshaler.
+func (m *FormMarshaler) Marshal(v interface{}) ([]byte, error) {
+	return m.responseMarshaler().Marshal(v)
+}
+
+// NewEncoder returns an Encoder of ResponseMarshaler.
+func (m *FormMarshaler) NewEncoder(w io.Writer) Encoder {
+	return m.responseMarshaler().NewEncoder(w)
+}
+
+// Unmarshal unmarshals a form "data" into "v".
+// "v" must be a proto.Message.
+func (m *FormMarshaler) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("unable to unmarshal a form into non proto field %T", v)
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	return PopulateQueryParameters(msg, values, utilities.NewDoubleArray(nil))
+}
+
+// NewDecoder returns a Decoder which reads a form from "r".
+// The Decoder reads the whole form on the first call and returns io.EOF on the subsequent calls.
+func (m *FormMarshaler) NewDecoder(r io.Reader) Decoder {
+	var done bool
+	return DecoderFunc(func(v interface{}) error {
+		if done {
+			return io.EOF
+		}
+		done = true
+
+		data, err := readAllWithLimit(r, m.maxBodySize())
+		if err != nil {
+			return err
+		}
+		return m.Unmarshal(data, v)
+	})
+}
+
+func (m *FormMarshaler) maxBodySize() int64 {
+	if m.MaxBodySize > 0 {
+		return m.MaxBodySize
+	}
+	return defaultMaxFormBodySize
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// readAllWithLimit reads "r" until EOF.
+// It returns errBodyTooLarge if "r" has more than "limit" bytes.
+func readAllWithLimit(r io.Reader, limit int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,74 @@
+package runtime_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+func TestFormUnmarshal(t *testing.T) {
+	const form = "string_value=foo&uint32Value=42&single_nested.name=bar&mapped_string_value[key]=value"
+	want := &examplepb.ABitOfEverything{
+		StringValue:       "foo",
+		Uint32Value:       42,
+		SingleNested:      &examplepb.ABitOfEverything_Nested{Name: "bar"},
+		MappedStringValue: map[string]string{"key": "value"},
+	}
+
+	var m runtime.FormMarshaler
+	got := new(examplepb.ABitOfEverything)
+	if err := m.Unmarshal([]byte(form), got); err != nil {
+		t.Fatalf("m.Unmarshal(%q, got) failed with %v; want success", form, err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("m.Unmarshal(%q, got); got = %v; want %v", form, got, want)
+	}
+
+	got = new(examplepb.ABitOfEverything)
+	dec := m.NewDecoder(strings.NewReader(form))
+	if err := dec.Decode(got); err != nil {
+		t.Fatalf("dec.Decode(got) failed with %v; want success", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("dec.Decode(got); got = %v; want %v", got, want)
+	}
+	if err := dec.Decode(got); err != io.EOF {
+		t.Errorf("dec.Decode(got) = %v; want %v", err, io.EOF)
+	}
+}
+
+func TestFormUnmarshalErrors(t *testing.T) {
+	for _, spec := range []struct {
+		m    runtime.FormMarshaler
+		form string
+		v    interface{}
+	}{
+		{form: "uint32_value=foo", v: new(examplepb.ABitOfEverything)},
+		{form: "string_value=%zz", v: new(examplepb.ABitOfEverything)},
+		{form: "string_value=foo", v: new(string)},
+		{m: runtime.FormMarshaler{MaxBodySize: 8}, form: "string_value=foo", v: new(examplepb.ABitOfEverything)},
+	} {
+		if err := spec.m.NewDecoder(strings.NewReader(spec.form)).Decode(spec.v); err == nil {
+			t.Errorf("m.NewDecoder(%q).Decode(%T) succeeded; want failure", spec.form, spec.v)
+		}
+	}
+}
+
+func TestFormMarshal(t *testing.T) {
+	var m runtime.FormMarshaler
+	if got, want := m.ContentType(), "application/json"; got != want {
+		t.Errorf("m.ContentType() = %q; want %q", got, want)
+	}
+	msg := &examplepb.SimpleMessage{Id: "foo"}
+	buf, err := m.Marshal(msg)
+	if err != nil {
+		t.Fatalf("m.Marshal(%v) failed with %v; want success", msg, err)
+	}
+	if got, want := string(buf), `{"id":"foo"}`; got != want {
+		t.Errorf("m.Marshal(%v) = %q; want %q", msg, got, want)
+	}
+}
@@ -0,0 +1,203 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+)
+
+const (
+	// defaultMaxMultipartBodySize is the default limit of the size of multipart bodies.
+	defaultMaxMultipartBodySize = int64(32 << 20)
+	// defaultMaxMultipartFileSize is the default limit of the size of each file part.
+	defaultMaxMultipartFileSize = int64(10 << 20)
+)
+
+// MultipartFormMarshaler is a Marshaler which unmarshals multipart/form-data request bodies.
+// Fields are mapped onto the message in the same way as PopulateQueryParameters.
+// The contents of file parts are set to bytes (or string) fields as they are.
+//
+// It is intended to be registered as an inbound Marshaler for "multipart/form-data" with WithMarshalerOption.
+// Responses are marshaled by ResponseMarshaler.
+type MultipartFormMarshaler struct {
+	// ResponseMarshaler marshals responses.
+	// If nil, the default JSON Marshaler of ServeMux is used.
+	ResponseMarshaler Marshaler
+	// MaxBodySize limits the total size of the parts in bytes.
+	// If zero, 32MB is used.
+	MaxBodySize int64
+	// MaxFileSize limits the size of each file part in bytes.
+	// If zero, 10MB is used.
+	MaxFileSize int64
+
+	// boundary is the boundary of the request which the Marshaler is bound to.
+	boundary string
+}
+
+var _ contentTypeBinder = (*MultipartFormMarshaler)(nil)
+
+func (m *MultipartFormMarshaler) bindContentType(contentType string) Marshaler {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		defaultLogger.Infof(context.Background(), "Failed to parse Content-Type %q: %v", contentType, err)
+		return m
+	}
+	bound := *m
+	bound.boundary = params["boundary"]
+	return &bound
+}
+
+func (m *MultipartFormMarshaler) responseMarshaler() Marshaler {
+	if m.ResponseMarshaler != nil {
+		return m.ResponseMarshaler
+	}
+	return defaultMarshaler
+}
+
+// ContentType returns the Content-Type of the responses.
+func (m *MultipartFormMarshaler) ContentType() string {
+	return m.responseMarshaler().ContentType()
+}
+
+// Marshal marshals "v" with ResponseMarshaler.
+func (m *MultipartFormMarshaler) Marshal(v interface{}) ([]byte, error) {
+	return m.responseMarshaler().Marshal(v)
+}
+
+// NewEncoder returns an Encoder of ResponseMarshaler.
+func (m *MultipartFormMarshaler) NewEncoder(w io.Writer) Encoder {
+	return m.responseMarshaler().NewEncoder(w)
+}
+
+// Unmarshal unmarshals a multipart "data" into "v".
+// "v" must be a proto.Message.
+func (m *MultipartFormMarshaler) Unmarshal(data []byte, v interface{}) error {
+	return m.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// NewDecoder returns a Decoder which reads a multipart body from "r".
+// The Decoder reads the whole body on the first call and returns io.EOF on the subsequent calls.
+func (m *MultipartFormMarshaler) NewDecoder(r io.Reader) Decoder {
+	var done bool
+	return DecoderFunc(func(v interface{}) error {
+		if done {
+			return io.EOF
+		}
+		done = true
+
+		msg, ok := v.(proto.Message)
+		if !ok {
+			return fmt.Errorf("unable to unmarshal a multipart body into non proto field %T", v)
+		}
+		if m.boundary == "" {
+			return errors.New("no multipart boundary in Content-Type")
+		}
+		return m.decode(multipart.NewReader(r, m.boundary), msg)
+	})
+}
+
+func (m *MultipartFormMarshaler) decode(r *multipart.Reader, msg proto.Message) error {
+	remaining := m.maxBodySize()
+	values := make(url.Values)
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := p.FormName()
+		if name == "" {
+			continue
+		}
+
+		limit := remaining
+		if p.FileName() != "" && m.maxFileSize() < limit {
+			limit = m.maxFileSize()
+		}
+		data, err := readAllWithLimit(p, limit)
+		if err == errBodyTooLarge {
+			return fmt.Errorf("multipart part %q too large", name)
+		}
+		if err != nil {
+			return err
+		}
+		remaining -= int64(len(data))
+
+		if p.FileName() != "" {
+			if err := populateBytesFieldFromPath(msg, strings.Split(name, "."), data); err != nil {
+				return err
+			}
+			continue
+		}
+		values.Add(name, string(data))
+	}
+	return PopulateQueryParameters(msg, values, utilities.NewDoubleArray(nil))
+}
+
+func (m *MultipartFormMarshaler) maxBodySize() int64 {
+	if m.MaxBodySize > 0 {
+		return m.MaxBodySize
+	}
+	return defaultMaxMultipartBodySize
+}
+
+func (m *MultipartFormMarshaler) maxFileSize() int64 {
+	if m.MaxFileSize > 0 {
+		return m.MaxFileSize
+	}
+	return defaultMaxMultipartFileSize
+}
+
+// populateBytesFieldFromPath sets "data" to the bytes or string field at "fieldPath" in "msg" without decoding it.
+func populateBytesFieldFromPath(msg proto.Message, fieldPath []string, data []byte) error {
+	m := reflect.ValueOf(msg)
+	if m.Kind() != reflect.Ptr {
+		return fmt.Errorf("unexpected type %T: %v", msg, msg)
+	}
+	m = m.Elem()
+	for i, fieldName := range fieldPath {
+		if m.Kind() != reflect.Struct {
+			return fmt.Errorf("non-aggregate type in the mid of path: %s", strings.Join(fieldPath, "."))
+		}
+		f, _, err := fieldByProtoName(m, fieldName)
+		if err != nil {
+			return err
+		} else if !f.IsValid() {
+			defaultLogger.Infof(context.Background(), "field not found in %T: %s", msg, strings.Join(fieldPath, "."))
+			return nil
+		}
+
+		if i < len(fieldPath)-1 {
+			if f.Kind() == reflect.Ptr {
+				if f.IsNil() {
+					f.Set(reflect.New(f.Type().Elem()))
+				}
+				f = f.Elem()
+			}
+			m = f
+			continue
+		}
+
+		switch {
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8:
+			f.SetBytes(data)
+		case f.Kind() == reflect.String:
+			f.SetString(string(data))
+		default:
+			return fmt.Errorf("file part for non bytes field: %s", strings.Join(fieldPath, "."))
+		}
+	}
+	return nil
+}
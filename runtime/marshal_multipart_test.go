@@ -0,0 +1,107 @@
+package runtime_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatalf("w.WriteField(%q, %q) failed with %v; want success", name, value, err)
+		}
+	}
+	for name, content := range files {
+		fw, err := w.CreateFormFile(name, "file.bin")
+		if err != nil {
+			t.Fatalf("w.CreateFormFile(%q, %q) failed with %v; want success", name, "file.bin", err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() failed with %v; want success", err)
+	}
+
+	r, err := http.NewRequest("POST", "http://example.com", &body)
+	if err != nil {
+		t.Fatalf("http.NewRequest failed with %v; want success", err)
+	}
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func TestMultipartFormDecode(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption("multipart/form-data", &runtime.MultipartFormMarshaler{}))
+	r := newMultipartRequest(t,
+		map[string]string{"string_value": "foo", "single_nested.name": "bar"},
+		map[string]string{"bytes_value": "\x00\x01binary"},
+	)
+
+	in, _ := runtime.MarshalerForRequest(mux, r)
+	got := new(examplepb.ABitOfEverything)
+	if err := in.NewDecoder(r.Body).Decode(got); err != nil {
+		t.Fatalf("in.NewDecoder(r.Body).Decode(got) failed with %v; want success", err)
+	}
+	want := &examplepb.ABitOfEverything{
+		StringValue:  "foo",
+		SingleNested: &examplepb.ABitOfEverything_Nested{Name: "bar"},
+		BytesValue:   []byte("\x00\x01binary"),
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("in.NewDecoder(r.Body).Decode(got); got = %v; want %v", got, want)
+	}
+}
+
+func TestMultipartFormDecodeErrors(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		m      *runtime.MultipartFormMarshaler
+		fields map[string]string
+		files  map[string]string
+	}{
+		{
+			name:  "file too large",
+			m:     &runtime.MultipartFormMarshaler{MaxFileSize: 4},
+			files: map[string]string{"bytes_value": "12345"},
+		},
+		{
+			name:   "body too large",
+			m:      &runtime.MultipartFormMarshaler{MaxBodySize: 4},
+			fields: map[string]string{"string_value": "12345"},
+		},
+		{
+			name:  "file for non bytes field",
+			m:     &runtime.MultipartFormMarshaler{},
+			files: map[string]string{"uint32_value": "12345"},
+		},
+		{
+			name:   "invalid value",
+			m:      &runtime.MultipartFormMarshaler{},
+			fields: map[string]string{"uint32_value": "foo"},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithMarshalerOption("multipart/form-data", spec.m))
+			r := newMultipartRequest(t, spec.fields, spec.files)
+			in, _ := runtime.MarshalerForRequest(mux, r)
+			if err := in.NewDecoder(r.Body).Decode(new(examplepb.ABitOfEverything)); err == nil {
+				t.Errorf("in.NewDecoder(r.Body).Decode succeeded; want failure")
+			}
+		})
+	}
+}
+
+func TestMultipartFormDecodeWithoutBoundary(t *testing.T) {
+	var m runtime.MultipartFormMarshaler
+	if err := m.NewDecoder(bytes.NewReader(nil)).Decode(new(examplepb.ABitOfEverything)); err == nil {
+		t.Errorf("m.NewDecoder(r).Decode succeeded without boundary; want failure")
+	}
+}
@@ -0,0 +1,86 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// ProtoText is a Marshaler which marshals/unmarshals into/from the protobuf text format.
+//
+// Streams are encoded as a sequence of messages separated by "---" lines.
+// Chunks of response streams are written as a field named "result" or "error" which contains the message.
+type ProtoText struct{}
+
+// ContentType always returns "text/plain".
+func (*ProtoText) ContentType() string {
+	return "text/plain"
+}
+
+// Marshal marshals "v" into the text format.
+func (*ProtoText) Marshal(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case proto.Message:
+		var buf bytes.Buffer
+		if err := proto.MarshalText(&buf, v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case map[string]proto.Message:
+		return marshalTextChunk(v)
+	}
+	return nil, errors.New("unable to marshal non proto field")
+}
+
+// marshalTextChunk marshals a chunk of a response stream as if it were a message whose fields are "chunk".
+func marshalTextChunk(chunk map[string]proto.Message) ([]byte, error) {
+	keys := make([]string, 0, len(chunk))
+	for k := range chunk {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		var field bytes.Buffer
+		if err := proto.MarshalText(&field, chunk[k]); err != nil {
+			return nil, err
+		}
+		buf.WriteString(k + " {\n")
+		for _, line := range bytes.SplitAfter(field.Bytes(), []byte("\n")) {
+			if len(line) > 0 {
+				buf.WriteString("  ")
+				buf.Write(line)
+			}
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal unmarshals the text format "data" into "v".
+func (*ProtoText) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("unable to unmarshal non proto field")
+	}
+	return proto.UnmarshalText(string(data), msg)
+}
+
+// NewDecoder returns a Decoder which reads a stream of messages in the text format from "r".
+func (t *ProtoText) NewDecoder(r io.Reader) Decoder {
+	return newDocumentDecoder(r, t.Unmarshal)
+}
+
+// NewEncoder returns an Encoder which writes a stream of messages in the text format into "w".
+func (t *ProtoText) NewEncoder(w io.Writer) Encoder {
+	return newDocumentEncoder(w, t.Marshal)
+}
+
+// Delimiter separates messages in response streams.
+func (*ProtoText) Delimiter() []byte {
+	return documentSeparator
+}
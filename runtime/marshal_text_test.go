@@ -0,0 +1,79 @@
+package runtime_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+func TestProtoTextMarshal(t *testing.T) {
+	msg := &examplepb.ABitOfEverything{
+		StringValue:  "foo",
+		SingleNested: &examplepb.ABitOfEverything_Nested{Name: "bar"},
+	}
+	m := new(runtime.ProtoText)
+	buf, err := m.Marshal(msg)
+	if err != nil {
+		t.Fatalf("m.Marshal(%v) failed with %v; want success", msg, err)
+	}
+	got := new(examplepb.ABitOfEverything)
+	if err := m.Unmarshal(buf, got); err != nil {
+		t.Fatalf("m.Unmarshal(%q, got) failed with %v; want success", buf, err)
+	}
+	if !proto.Equal(got, msg) {
+		t.Errorf("m.Unmarshal(%q, got); got = %v; want %v", buf, got, msg)
+	}
+
+	if _, err := m.Marshal("foo"); err == nil {
+		t.Errorf("m.Marshal(%q) succeeded; want failure", "foo")
+	}
+	if err := m.Unmarshal([]byte(`"foo"`), new(string)); err == nil {
+		t.Errorf("m.Unmarshal(%q, new(string)) succeeded; want failure", `"foo"`)
+	}
+}
+
+func TestProtoTextMarshalStreamChunk(t *testing.T) {
+	chunk := map[string]proto.Message{"result": &examplepb.SimpleMessage{Id: "foo"}}
+	buf, err := new(runtime.ProtoText).Marshal(chunk)
+	if err != nil {
+		t.Fatalf("m.Marshal(%v) failed with %v; want success", chunk, err)
+	}
+	if got, want := strings.Replace(string(buf), ":  ", ": ", -1), "result {\n  id: \"foo\"\n}\n"; got != want {
+		t.Errorf("m.Marshal(%v) = %q; want %q", chunk, got, want)
+	}
+}
+
+func TestProtoTextStream(t *testing.T) {
+	msgs := []proto.Message{
+		&examplepb.SimpleMessage{Id: "foo"},
+		&examplepb.SimpleMessage{Id: "bar"},
+	}
+	m := new(runtime.ProtoText)
+
+	var buf bytes.Buffer
+	enc := m.NewEncoder(&buf)
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("enc.Encode(%v) failed with %v; want success", msg, err)
+		}
+	}
+
+	dec := m.NewDecoder(&buf)
+	for _, want := range msgs {
+		got := new(examplepb.SimpleMessage)
+		if err := dec.Decode(got); err != nil {
+			t.Fatalf("dec.Decode(got) failed with %v; want success", err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("dec.Decode(got); got = %v; want %v", got, want)
+		}
+	}
+	if err := dec.Decode(new(examplepb.SimpleMessage)); err != io.EOF {
+		t.Errorf("dec.Decode(got) = %v; want %v", err, io.EOF)
+	}
+}
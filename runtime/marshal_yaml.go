@@ -0,0 +1,114 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/ghodss/yaml"
+)
+
+// documentSeparator separates documents in a stream of YAMLPb or ProtoText.
+var documentSeparator = []byte("---\n")
+
+// YAMLPb is a Marshaler which marshals/unmarshals into/from YAML.
+// It goes through the JSON mapping of JSONPb, so field names and well known types
+// are represented in the same way as JSONPb with the same options.
+//
+// Streams are encoded as a sequence of YAML documents separated by "---".
+type YAMLPb JSONPb
+
+// ContentType always returns "application/yaml".
+func (*YAMLPb) ContentType() string {
+	return "application/yaml"
+}
+
+// Marshal marshals "v" into YAML.
+func (y *YAMLPb) Marshal(v interface{}) ([]byte, error) {
+	j, err := (*JSONPb)(y).Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(j)
+}
+
+// Unmarshal unmarshals YAML "data" into "v".
+func (y *YAMLPb) Unmarshal(data []byte, v interface{}) error {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return err
+	}
+	return (*JSONPb)(y).Unmarshal(j, v)
+}
+
+// NewDecoder returns a Decoder which reads a stream of YAML documents from "r".
+func (y *YAMLPb) NewDecoder(r io.Reader) Decoder {
+	return newDocumentDecoder(r, y.Unmarshal)
+}
+
+// NewEncoder returns an Encoder which writes a stream of YAML documents into "w".
+func (y *YAMLPb) NewEncoder(w io.Writer) Encoder {
+	return newDocumentEncoder(w, y.Marshal)
+}
+
+// Delimiter separates YAML documents in response streams.
+func (*YAMLPb) Delimiter() []byte {
+	return documentSeparator
+}
+
+// newDocumentDecoder returns a Decoder which splits "r" into documents separated by "---" lines
+// and unmarshals each of them with "unmarshal". Empty documents are skipped.
+func newDocumentDecoder(r io.Reader, unmarshal func([]byte, interface{}) error) Decoder {
+	br := bufio.NewReader(r)
+	return DecoderFunc(func(v interface{}) error {
+		for {
+			doc, err := readDocument(br)
+			if len(bytes.TrimSpace(doc)) == 0 {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil && err != io.EOF {
+				return err
+			}
+			return unmarshal(doc, v)
+		}
+	})
+}
+
+// readDocument reads lines from "r" until a "---" line or the end of "r".
+// It returns io.EOF with the last document.
+func readDocument(r *bufio.Reader) ([]byte, error) {
+	var doc []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		if bytes.Equal(bytes.TrimRight(line, "\r\n"), documentSeparator[:3]) {
+			return doc, nil
+		}
+		doc = append(doc, line...)
+		if err != nil {
+			return doc, err
+		}
+	}
+}
+
+// newDocumentEncoder returns an Encoder which marshals values with "marshal"
+// and writes them into "w" separated by "---" lines.
+func newDocumentEncoder(w io.Writer, marshal func(interface{}) ([]byte, error)) Encoder {
+	var started bool
+	return EncoderFunc(func(v interface{}) error {
+		buf, err := marshal(v)
+		if err != nil {
+			return err
+		}
+		if started {
+			if _, err := w.Write(documentSeparator); err != nil {
+				return err
+			}
+		}
+		started = true
+		_, err = w.Write(buf)
+		return err
+	})
+}
@@ -0,0 +1,91 @@
+package runtime_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+func TestYAMLPbMarshal(t *testing.T) {
+	msg := &examplepb.ABitOfEverything{
+		StringValue:  "foo",
+		Int64Value:   -42,
+		SingleNested: &examplepb.ABitOfEverything_Nested{Name: "bar", Amount: 10},
+	}
+	m := &runtime.YAMLPb{OrigName: true}
+	buf, err := m.Marshal(msg)
+	if err != nil {
+		t.Fatalf("m.Marshal(%v) failed with %v; want success", msg, err)
+	}
+	for _, want := range []string{
+		"string_value: foo\n",
+		"int64_value: \"-42\"\n",
+		"single_nested:\n  amount: 10\n  name: bar\n",
+	} {
+		if !strings.Contains(string(buf), want) {
+			t.Errorf("m.Marshal(%v) = %q; want to contain %q", msg, buf, want)
+		}
+	}
+
+	got := new(examplepb.ABitOfEverything)
+	if err := m.Unmarshal(buf, got); err != nil {
+		t.Fatalf("m.Unmarshal(%q, got) failed with %v; want success", buf, err)
+	}
+	if !proto.Equal(got, msg) {
+		t.Errorf("m.Unmarshal(%q, got); got = %v; want %v", buf, got, msg)
+	}
+}
+
+func TestYAMLPbUnmarshalJSONNames(t *testing.T) {
+	const data = "stringValue: foo\nsingleNested:\n  name: bar\n"
+	got := new(examplepb.ABitOfEverything)
+	if err := new(runtime.YAMLPb).Unmarshal([]byte(data), got); err != nil {
+		t.Fatalf("m.Unmarshal(%q, got) failed with %v; want success", data, err)
+	}
+	want := &examplepb.ABitOfEverything{
+		StringValue:  "foo",
+		SingleNested: &examplepb.ABitOfEverything_Nested{Name: "bar"},
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("m.Unmarshal(%q, got); got = %v; want %v", data, got, want)
+	}
+}
+
+func TestYAMLPbStream(t *testing.T) {
+	msgs := []proto.Message{
+		&examplepb.SimpleMessage{Id: "foo"},
+		&examplepb.SimpleMessage{Id: "bar"},
+	}
+	m := new(runtime.YAMLPb)
+
+	var buf bytes.Buffer
+	enc := m.NewEncoder(&buf)
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("enc.Encode(%v) failed with %v; want success", msg, err)
+		}
+	}
+	if got, want := buf.String(), "id: foo\n---\nid: bar\n"; got != want {
+		t.Errorf("buf = %q; want %q", got, want)
+	}
+
+	// Leading separators and empty documents are allowed.
+	dec := m.NewDecoder(strings.NewReader("---\n" + buf.String() + "---\n"))
+	for _, want := range msgs {
+		got := new(examplepb.SimpleMessage)
+		if err := dec.Decode(got); err != nil {
+			t.Fatalf("dec.Decode(got) failed with %v; want success", err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("dec.Decode(got); got = %v; want %v", got, want)
+		}
+	}
+	if err := dec.Decode(new(examplepb.SimpleMessage)); err != io.EOF {
+		t.Errorf("dec.Decode(got) = %v; want %v", err, io.EOF)
+	}
+}
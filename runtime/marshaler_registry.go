@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"errors"
+	"mime"
 	"net/http"
 )
 
@@ -20,33 +21,75 @@ var (
 // It checks the registry on the ServeMux for the MIME type set by the Content-Type header.
 // If it isn't set (or the request Content-Type is empty), checks for "*".
 // If there are multiple Content-Type headers set, choose the first one that it can
-// exactly match in the registry.
+// exactly match in the registry. If none of them exactly matches, the media types without
+// parameters are tried in the same way.
 // Otherwise, it follows the above logic for "*"/InboundMarshaler/OutboundMarshaler.
 func MarshalerForRequest(mux *ServeMux, r *http.Request) (inbound Marshaler, outbound Marshaler) {
+	// outboundMIME is the MIME type which "outbound" is registered for.
+	var outboundMIME string
 	for _, acceptVal := range r.Header[acceptHeader] {
 		if m, ok := mux.marshalers.mimeMap[acceptVal]; ok {
-			outbound = m
+			outbound, outboundMIME = m, acceptVal
 			break
 		}
 	}
+	if outbound == nil && mux.outputOptionsFromRequest {
+		// Retry without the parameters, which may carry OutputOptions.
+		for _, acceptVal := range r.Header[acceptHeader] {
+			mediaType, _, err := mime.ParseMediaType(acceptVal)
+			if err != nil {
+				continue
+			}
+			if m, ok := mux.marshalers.mimeMap[mediaType]; ok {
+				outbound, outboundMIME = m, mediaType
+				break
+			}
+		}
+	}
 
+	var contentType, inboundMIME string
 	for _, contentTypeVal := range r.Header[contentTypeHeader] {
 		if m, ok := mux.marshalers.mimeMap[contentTypeVal]; ok {
-			inbound = m
+			inbound, contentType, inboundMIME = m, contentTypeVal, contentTypeVal
 			break
 		}
 	}
+	if inbound == nil {
+		// Retry without the parameters, e.g. the boundary of multipart/form-data.
+		for _, contentTypeVal := range r.Header[contentTypeHeader] {
+			mediaType, _, err := mime.ParseMediaType(contentTypeVal)
+			if err != nil {
+				continue
+			}
+			if m, ok := mux.marshalers.mimeMap[mediaType]; ok {
+				inbound, contentType, inboundMIME = m, contentTypeVal, mediaType
+				break
+			}
+		}
+	}
 
 	if inbound == nil {
-		inbound = mux.marshalers.mimeMap[MIMEWildcard]
+		inbound, inboundMIME = mux.marshalers.mimeMap[MIMEWildcard], MIMEWildcard
+	}
+	if b, ok := inbound.(contentTypeBinder); ok {
+		inbound = b.bindContentType(contentType)
 	}
 	if outbound == nil {
-		outbound = inbound
+		outbound, outboundMIME = inbound, inboundMIME
+	}
+	if mux.outputOptionsFromRequest {
+		outbound = mux.marshalerWithOutputOptions(r, outboundMIME, outbound)
 	}
 
 	return inbound, outbound
 }
 
+// contentTypeBinder is implemented by Marshalers which depend on the parameters of the Content-Type of requests.
+// MarshalerForRequest binds such Marshalers to the Content-Type of each request.
+type contentTypeBinder interface {
+	bindContentType(contentType string) Marshaler
+}
+
 // marshalerRegistry is a mapping from MIME types to Marshalers.
 type marshalerRegistry struct {
 	mimeMap map[string]Marshaler
@@ -76,6 +76,23 @@ func TestMarshalerForRequest(t *testing.T) {
 	}
 }
 
+func TestMarshalerForRequestWithMediaTypeParameters(t *testing.T) {
+	r, err := http.NewRequest("POST", "http://example.com", nil)
+	if err != nil {
+		t.Fatalf(`http.NewRequest("POST", "http://example.com", nil) failed with %v; want success`, err)
+	}
+	r.Header.Set("Content-Type", "application/x-in; charset=utf-8")
+
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption("application/x-in", &runtime.JSONBuiltin{}))
+	in, out := runtime.MarshalerForRequest(mux, r)
+	if _, ok := in.(*runtime.JSONBuiltin); !ok {
+		t.Errorf("in = %#v; want a runtime.JSONBuiltin", in)
+	}
+	if _, ok := out.(*runtime.JSONBuiltin); !ok {
+		t.Errorf("out = %#v; want a runtime.JSONBuiltin", out)
+	}
+}
+
 type dummyMarshaler struct{}
 
 func (dummyMarshaler) ContentType() string { return "" }
@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataKeyList is a list of metadata keys.
+// A key which ends with "*" matches the keys which start with the rest of it.
+type metadataKeyList []string
+
+func newMetadataKeyList(keys []string) metadataKeyList {
+	l := make(metadataKeyList, 0, len(keys))
+	for _, k := range keys {
+		l = append(l, strings.ToLower(k))
+	}
+	return l
+}
+
+func (l metadataKeyList) contains(key string) bool {
+	for _, k := range l {
+		if strings.HasSuffix(k, "*") {
+			if strings.HasPrefix(key, k[:len(k)-1]) {
+				return true
+			}
+		} else if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// WithIncomingMetadataAllowList returns a ServeMuxOption which makes AnnotateContext forward only the metadata
+// with "keys" among the metadata taken from the headers of the request, e.g. "Grpc-Metadata-*" or "Authorization" headers.
+// A key which ends with "*", e.g. "x-client-*", matches the keys which start with the rest of it.
+// Keys are case-insensitive.
+//
+// The metadata written by the gateway itself and by the annotators given to WithMetadata are not affected.
+func WithIncomingMetadataAllowList(keys ...string) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.incomingMetadataAllowList = newMetadataKeyList(keys)
+	}
+}
+
+// WithIncomingMetadataDenyList returns a ServeMuxOption which makes AnnotateContext drop the metadata
+// with "keys" among the metadata taken from the headers of the request.
+// Keys are matched in the same way as WithIncomingMetadataAllowList.
+//
+// Use it for the metadata which your gRPC servers trust, e.g. identities set by a WithMetadata annotator.
+func WithIncomingMetadataDenyList(keys ...string) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.incomingMetadataDenyList = append(serveMux.incomingMetadataDenyList, newMetadataKeyList(keys)...)
+	}
+}
+
+// WithIncomingMetadataLimits returns a ServeMuxOption which makes AnnotateContext reject requests
+// with more than "maxCount" metadata entries taken from the headers, or with more than "maxSize" bytes of them in total.
+// The size of an entry is the length of its key plus the length of its value.
+// 0 means no limit.
+//
+// The rejected requests get InvalidArgument statuses.
+func WithIncomingMetadataLimits(maxCount, maxSize int) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.incomingMetadataMaxCount = maxCount
+		serveMux.incomingMetadataMaxSize = maxSize
+	}
+}
+
+// filterIncomingMetadata applies the limits and the allow and deny lists to "pairs" taken from the headers of the request.
+// It also drops the keys in "reserved", which the gateway or the annotators write for the request.
+func (s *ServeMux) filterIncomingMetadata(ctx context.Context, pairs []string, reserved metadata.MD) ([]string, error) {
+	if max := s.incomingMetadataMaxCount; max > 0 && len(pairs)/2 > max {
+		return nil, status.Errorf(codes.InvalidArgument, "too many metadata headers: %d, limit: %d", len(pairs)/2, max)
+	}
+	if max := s.incomingMetadataMaxSize; max > 0 {
+		var size int
+		for _, p := range pairs {
+			size += len(p)
+		}
+		if size > max {
+			return nil, status.Errorf(codes.InvalidArgument, "metadata headers too large: %d bytes, limit: %d", size, max)
+		}
+	}
+
+	filtered := pairs[:0]
+	for i := 0; i < len(pairs); i += 2 {
+		key := strings.ToLower(pairs[i])
+		switch {
+		case len(reserved[key]) > 0:
+			s.Logger().Debugf(ctx, "dropped incoming metadata %q written by the gateway", key)
+		case s.incomingMetadataAllowList != nil && !s.incomingMetadataAllowList.contains(key):
+			s.Logger().Debugf(ctx, "dropped incoming metadata %q not in the allow list", key)
+		case s.incomingMetadataDenyList.contains(key):
+			s.Logger().Debugf(ctx, "dropped incoming metadata %q in the deny list", key)
+		default:
+			filtered = append(filtered, pairs[i], pairs[i+1])
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,121 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAnnotateContextFiltersIncomingMetadata(t *testing.T) {
+	header := http.Header{
+		"Authorization":                    {"Bearer token"},
+		"Grpc-Metadata-Foo":                {"foo"},
+		"Grpc-Metadata-X-User-Id":          {"spoofed"},
+		"Grpc-Metadata-X-Internal-Role":    {"admin"},
+		"Grpc-Metadata-X-Forwarded-For":    {"203.0.113.1"},
+		"Grpc-Metadata-Grpcgateway-Accept": {"spoofed"},
+		"Accept":                           {"application/json"},
+	}
+	annotator := func(ctx context.Context, req *http.Request) metadata.MD {
+		return metadata.Pairs("x-user-id", "alice")
+	}
+	for _, spec := range []struct {
+		name   string
+		opts   []runtime.ServeMuxOption
+		want   metadata.MD
+		absent []string
+	}{
+		{
+			name: "default",
+			want: metadata.MD{
+				"authorization":      {"Bearer token"},
+				"foo":                {"foo"},
+				"x-user-id":          {"alice"},
+				"x-internal-role":    {"admin"},
+				"x-forwarded-for":    {"192.0.2.1"},
+				"grpcgateway-accept": {"application/json"},
+			},
+		},
+		{
+			name: "deny list",
+			opts: []runtime.ServeMuxOption{runtime.WithIncomingMetadataDenyList("X-Internal-*")},
+			want: metadata.MD{
+				"foo":       {"foo"},
+				"x-user-id": {"alice"},
+			},
+			absent: []string{"x-internal-role"},
+		},
+		{
+			name: "allow list",
+			opts: []runtime.ServeMuxOption{runtime.WithIncomingMetadataAllowList("foo", "grpcgateway-*")},
+			want: metadata.MD{
+				"foo":                {"foo"},
+				"x-user-id":          {"alice"},
+				"x-forwarded-for":    {"192.0.2.1"},
+				"grpcgateway-accept": {"application/json"},
+			},
+			absent: []string{"authorization", "x-internal-role"},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://example.com/foo", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest failed with %v; want success", err)
+			}
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header = header
+
+			mux := runtime.NewServeMux(append(spec.opts, runtime.WithMetadata(annotator))...)
+			ctx, err := runtime.AnnotateContext(context.Background(), mux, req)
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+			}
+			md, _ := metadata.FromOutgoingContext(ctx)
+			for k, want := range spec.want {
+				if got := md[k]; !reflect.DeepEqual(got, want) {
+					t.Errorf("md[%q] = %q; want %q", k, got, want)
+				}
+			}
+			for _, k := range spec.absent {
+				if got, ok := md[k]; ok {
+					t.Errorf("md[%q] = %q; want no value", k, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAnnotateContextIncomingMetadataLimits(t *testing.T) {
+	for _, spec := range []struct {
+		name     string
+		maxCount int
+		maxSize  int
+		wantCode codes.Code
+	}{
+		{name: "no limit", wantCode: codes.OK},
+		{name: "within limits", maxCount: 2, maxSize: 64, wantCode: codes.OK},
+		{name: "too many", maxCount: 1, wantCode: codes.InvalidArgument},
+		{name: "too large", maxSize: 10, wantCode: codes.InvalidArgument},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://example.com/foo", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest failed with %v; want success", err)
+			}
+			req.Header.Set("Grpc-Metadata-Foo", "foo")
+			req.Header.Set("Grpc-Metadata-Bar", "bar")
+
+			mux := runtime.NewServeMux(runtime.WithIncomingMetadataLimits(spec.maxCount, spec.maxSize))
+			_, err = runtime.AnnotateContext(context.Background(), mux, req)
+			if got := status.Code(err); got != spec.wantCode {
+				t.Errorf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want code %v", err, spec.wantCode)
+			}
+		})
+	}
+}
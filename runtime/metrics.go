@@ -0,0 +1,307 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	// DefaultLatencyBuckets are the upper bounds in seconds of the buckets of the latency histograms of Metrics.
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the upper bounds in bytes of the buckets of the size histograms of Metrics.
+	DefaultSizeBuckets = []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+)
+
+// Metrics collects the metrics of the requests handled by ServeMux per route.
+// It is an http.Handler which exposes them in the Prometheus text format, e.g.
+//
+//	metrics := runtime.NewMetrics()
+//	mux := runtime.NewServeMux(runtime.WithMetrics(metrics))
+//	http.Handle("/metrics", metrics)
+//
+// The metrics are labelled by "http_method" and "pattern", the path template of the route, e.g. "/v1/{name=messages/*}",
+// and "grpc_method", the full name of the RPC method. The request counter and the latency histogram are also labelled
+// by "grpc_code", the status of the RPC.
+//
+//	grpc_gateway_requests_total                      counter
+//	grpc_gateway_request_duration_seconds            histogram
+//	grpc_gateway_request_size_bytes                  histogram
+//	grpc_gateway_response_size_bytes                 histogram
+//	grpc_gateway_stream_messages_sent_total          counter, the messages sent to request streams
+//	grpc_gateway_stream_messages_received_total      counter, the messages received from response streams
+//
+// The latency histogram is kept per "grpc_code" while the size histograms and the stream counters are not,
+// so they sum up the requests of a route whatever their status.
+//
+// Requests which do not match any route are recorded with an empty "pattern" and "grpc_method",
+// and the status which the error is reported with, e.g. "Unimplemented" if no route has the path.
+type Metrics struct {
+	mu     sync.Mutex
+	routes map[routeLabels]*routeMetrics
+}
+
+type routeLabels struct {
+	httpMethod string
+	pattern    string
+	grpcMethod string
+}
+
+type routeMetrics struct {
+	latencies              map[codes.Code]*histogram
+	requestSizes           *histogram
+	responseSizes          *histogram
+	streamMessagesSent     int64
+	streamMessagesReceived int64
+}
+
+// NewMetrics returns a new Metrics which has no metrics collected.
+func NewMetrics() *Metrics {
+	return &Metrics{routes: make(map[routeLabels]*routeMetrics)}
+}
+
+// WithMetrics returns a ServeMuxOption which makes ServeMux collect the metrics of the requests into "m".
+// A Metrics can be shared by multiple ServeMuxes.
+func WithMetrics(m *Metrics) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.metrics = m
+	}
+}
+
+// StreamMessageSent records that a message of a request stream has been sent to the gRPC server.
+// The generated handlers call this for each message.
+func StreamMessageSent(ctx context.Context) {
+	if call := rpcCallFromContext(ctx, nil); call != nil {
+		atomic.AddInt64(&call.streamMessagesSent, 1)
+	}
+}
+
+// streamMessageReceived records that a message of a response stream has been received from the gRPC server.
+func streamMessageReceived(ctx context.Context, req *http.Request) {
+	if call := rpcCallFromContext(ctx, req); call != nil {
+		atomic.AddInt64(&call.streamMessagesReceived, 1)
+	}
+}
+
+// observe records the metrics of "call" after the response is written.
+func (m *Metrics) observe(call *rpcCall) {
+	if m == nil {
+		return
+	}
+	labels := routeLabels{
+		httpMethod: call.span.HTTPMethod,
+		pattern:    call.span.Pattern,
+		grpcMethod: call.span.RPCMethod,
+	}
+	code := call.status().Code()
+	elapsed := time.Since(call.start).Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.routes[labels]
+	if !ok {
+		r = &routeMetrics{
+			latencies:     make(map[codes.Code]*histogram),
+			requestSizes:  newHistogram(DefaultSizeBuckets),
+			responseSizes: newHistogram(DefaultSizeBuckets),
+		}
+		m.routes[labels] = r
+	}
+	h, ok := r.latencies[code]
+	if !ok {
+		h = newHistogram(DefaultLatencyBuckets)
+		r.latencies[code] = h
+	}
+	h.observe(elapsed)
+	r.requestSizes.observe(float64(atomic.LoadInt64(&call.requestBytes)))
+	r.responseSizes.observe(float64(atomic.LoadInt64(&call.responseBytes)))
+	r.streamMessagesSent += atomic.LoadInt64(&call.streamMessagesSent)
+	r.streamMessagesReceived += atomic.LoadInt64(&call.streamMessagesReceived)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	m.write(bw)
+	if err := bw.Flush(); err != nil {
+		defaultLogger.Infof(r.Context(), "Failed to write metrics: %v", err)
+	}
+}
+
+func (m *Metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := make([]routeLabels, 0, len(m.routes))
+	for l := range m.routes {
+		routes = append(routes, l)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.pattern != b.pattern {
+			return a.pattern < b.pattern
+		}
+		if a.httpMethod != b.httpMethod {
+			return a.httpMethod < b.httpMethod
+		}
+		return a.grpcMethod < b.grpcMethod
+	})
+
+	writeMetricHeader(w, "grpc_gateway_requests_total", "counter", "Total number of requests handled by the gateway.")
+	for _, l := range routes {
+		r := m.routes[l]
+		for _, code := range sortedCodes(r.latencies) {
+			fmt.Fprintf(w, "grpc_gateway_requests_total{%s} %d\n", l.withCode(code), r.latencies[code].count)
+		}
+	}
+	writeMetricHeader(w, "grpc_gateway_request_duration_seconds", "histogram", "Latency of the requests handled by the gateway.")
+	for _, l := range routes {
+		r := m.routes[l]
+		for _, code := range sortedCodes(r.latencies) {
+			r.latencies[code].write(w, "grpc_gateway_request_duration_seconds", l.withCode(code))
+		}
+	}
+	writeMetricHeader(w, "grpc_gateway_request_size_bytes", "histogram", "Size of the request bodies.")
+	for _, l := range routes {
+		m.routes[l].requestSizes.write(w, "grpc_gateway_request_size_bytes", l.String())
+	}
+	writeMetricHeader(w, "grpc_gateway_response_size_bytes", "histogram", "Size of the response bodies.")
+	for _, l := range routes {
+		m.routes[l].responseSizes.write(w, "grpc_gateway_response_size_bytes", l.String())
+	}
+	writeMetricHeader(w, "grpc_gateway_stream_messages_sent_total", "counter", "Total number of messages sent to request streams.")
+	for _, l := range routes {
+		fmt.Fprintf(w, "grpc_gateway_stream_messages_sent_total{%s} %d\n", l, m.routes[l].streamMessagesSent)
+	}
+	writeMetricHeader(w, "grpc_gateway_stream_messages_received_total", "counter", "Total number of messages received from response streams.")
+	for _, l := range routes {
+		fmt.Fprintf(w, "grpc_gateway_stream_messages_received_total{%s} %d\n", l, m.routes[l].streamMessagesReceived)
+	}
+}
+
+func writeMetricHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func sortedCodes(m map[codes.Code]*histogram) []codes.Code {
+	cs := make([]codes.Code, 0, len(m))
+	for c := range m {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i] < cs[j] })
+	return cs
+}
+
+// String returns the labels in the Prometheus text format.
+func (l routeLabels) String() string {
+	return fmt.Sprintf(`http_method="%s",pattern="%s",grpc_method="%s"`,
+		escapeLabelValue(l.httpMethod), escapeLabelValue(l.pattern), escapeLabelValue(l.grpcMethod))
+}
+
+func (l routeLabels) withCode(code codes.Code) string {
+	return fmt.Sprintf(`%s,grpc_code="%s"`, l, code)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+// histogram is a Prometheus histogram with fixed buckets.
+type histogram struct {
+	bounds []float64
+	// counts are the numbers of observations in each bucket, not cumulative.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	if i := sort.SearchFloat64s(h.bounds, v); i < len(h.bounds) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) write(w io.Writer, name, labels string) {
+	var cumulative uint64
+	for i, b := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(b), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// metricsRequestBody counts the bytes read from the body of a request.
+type metricsRequestBody struct {
+	io.ReadCloser
+	call *rpcCall
+}
+
+func (b *metricsRequestBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.call.requestBytes, int64(n))
+	return n, err
+}
+
+// metricsResponseWriter counts the bytes written to the body of a response.
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	call *rpcCall
+}
+
+func (w *metricsResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	atomic.AddInt64(&w.call.responseBytes, int64(n))
+	return n, err
+}
+
+// Flush implements http.Flusher for ForwardResponseStream.
+func (w *metricsResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// CloseNotify implements http.CloseNotifier if the underlying http.ResponseWriter does.
+func (w *metricsResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// Hijack implements http.Hijacker if the underlying http.ResponseWriter does.
+func (w *metricsResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Push implements http.Pusher if the underlying http.ResponseWriter does.
+func (w *metricsResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
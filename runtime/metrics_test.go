@@ -0,0 +1,138 @@
+package runtime_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServeMuxWithMetrics(t *testing.T) {
+	pat, err := runtime.NewPattern(1, []int{int(utilities.OpLitPush), 0, int(utilities.OpPush), 0, int(utilities.OpConcatN), 1, int(utilities.OpCapture), 1}, []string{"v1", "name"}, "")
+	if err != nil {
+		t.Fatalf("runtime.NewPattern failed with %v; want success", err)
+	}
+	metrics := runtime.NewMetrics()
+	mux := runtime.NewServeMux(runtime.WithMetrics(metrics))
+	mux.Handle("POST", pat, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := runtime.NewRPCMethodContext(r.Context(), "/pkg.Service/Create")
+		ctx, err := runtime.AnnotateContext(ctx, mux, r)
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+		}
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			t.Fatalf("ioutil.ReadAll(r.Body) failed with %v; want success", err)
+		}
+		if pathParams["name"] == "missing" {
+			runtime.HTTPError(ctx, mux, &runtime.JSONBuiltin{}, w, r, status.Error(codes.NotFound, "not found"))
+			return
+		}
+		w.Write([]byte(`{"name":"foo"}`))
+	})
+	mux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := runtime.NewRPCMethodContext(r.Context(), "/pkg.Service/Watch")
+		ctx, err := runtime.AnnotateContext(ctx, mux, r)
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+		}
+		runtime.StreamMessageSent(ctx)
+		msgs := []proto.Message{&pb.SimpleMessage{Id: "a"}, &pb.SimpleMessage{Id: "b"}}
+		recv := func() (proto.Message, error) {
+			if len(msgs) == 0 {
+				return nil, io.EOF
+			}
+			msg := msgs[0]
+			msgs = msgs[1:]
+			return msg, nil
+		}
+		ctx = runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{})
+		runtime.ForwardResponseStream(ctx, mux, &runtime.JSONBuiltin{}, w, r, recv)
+	})
+
+	for _, spec := range []struct {
+		method, path, body string
+	}{
+		{method: "POST", path: "/v1/foo", body: `{"id":"1"}`},
+		{method: "POST", path: "/v1/bar", body: `{"id":"2"}`},
+		{method: "POST", path: "/v1/missing", body: `{}`},
+		{method: "GET", path: "/v1/foo"},
+		{method: "GET", path: "/v2/foo"},
+		{method: "DELETE", path: "/v1/foo"},
+	} {
+		req, err := http.NewRequest(spec.method, "http://example.com"+spec.path, bytes.NewBufferString(spec.body))
+		if err != nil {
+			t.Fatalf("http.NewRequest failed with %v; want success", err)
+		}
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if got, want := w.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; got != want {
+		t.Errorf(`w.Header().Get("Content-Type") = %q; want %q`, got, want)
+	}
+	got := w.Body.String()
+	create := `http_method="POST",pattern="/v1/{name=*}",grpc_method="/pkg.Service/Create"`
+	watch := `http_method="GET",pattern="/v1/{name=*}",grpc_method="/pkg.Service/Watch"`
+	notFound := `http_method="GET",pattern="",grpc_method=""`
+	notAllowed := `http_method="DELETE",pattern="",grpc_method=""`
+	for _, want := range []string{
+		"# TYPE grpc_gateway_requests_total counter\n",
+		"grpc_gateway_requests_total{" + create + `,grpc_code="OK"} 2` + "\n",
+		"grpc_gateway_requests_total{" + create + `,grpc_code="NotFound"} 1` + "\n",
+		"grpc_gateway_requests_total{" + watch + `,grpc_code="OK"} 1` + "\n",
+		"# TYPE grpc_gateway_request_duration_seconds histogram\n",
+		"grpc_gateway_request_duration_seconds_count{" + create + `,grpc_code="OK"} 2` + "\n",
+		"grpc_gateway_request_duration_seconds_bucket{" + create + `,grpc_code="OK",le="+Inf"} 2` + "\n",
+		"grpc_gateway_request_size_bytes_sum{" + create + "} 22\n",
+		"grpc_gateway_request_size_bytes_bucket{" + create + `,le="64"} 3` + "\n",
+		"grpc_gateway_response_size_bytes_count{" + create + "} 3\n",
+		"grpc_gateway_stream_messages_sent_total{" + create + "} 0\n",
+		"grpc_gateway_stream_messages_sent_total{" + watch + "} 1\n",
+		"grpc_gateway_stream_messages_received_total{" + watch + "} 2\n",
+		"grpc_gateway_requests_total{" + notFound + `,grpc_code="Unimplemented"} 1` + "\n",
+		"grpc_gateway_requests_total{" + notAllowed + `,grpc_code="Unimplemented"} 1` + "\n",
+		"grpc_gateway_response_size_bytes_count{" + notFound + "} 1\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics do not contain %q; got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "/v2/") {
+		t.Errorf("metrics contain an unmatched path; got:\n%s", got)
+	}
+}
+
+func TestServeMuxWithMetricsKeepsResponseWriterInterfaces(t *testing.T) {
+	pat, err := runtime.NewPattern(1, []int{int(utilities.OpLitPush), 0}, []string{"foo"}, "")
+	if err != nil {
+		t.Fatalf("runtime.NewPattern failed with %v; want success", err)
+	}
+	mux := runtime.NewServeMux(runtime.WithMetrics(runtime.NewMetrics()))
+	var hijacker, closeNotifier, pusher bool
+	mux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		_, hijacker = w.(http.Hijacker)
+		_, closeNotifier = w.(http.CloseNotifier)
+		_, pusher = w.(http.Pusher)
+	})
+
+	w := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+	if !hijacker {
+		t.Errorf("hijacker = false; want the underlying http.Hijacker to be available")
+	}
+	if closeNotifier || pusher {
+		t.Errorf("closeNotifier = %v, pusher = %v; want false as the underlying http.ResponseWriter does not implement them", closeNotifier, pusher)
+	}
+}
@@ -3,8 +3,10 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc/codes"
@@ -26,6 +28,35 @@ type ServeMux struct {
 	outgoingHeaderMatcher  HeaderMatcherFunc
 	metadataAnnotators     []func(context.Context, *http.Request) metadata.MD
 	protoErrorHandler      ProtoErrorHandlerFunc
+	errorDetailHandlers    map[string]ErrorDetailHandlerFunc
+	statusMapping          map[codes.Code]int
+	streamErrorHandler     StreamErrorHandlerFunc
+	// outputOptionsFromRequest is true if clients can choose OutputOptions per request.
+	outputOptionsFromRequest bool
+	// marshalerVariants is the variants of the registered marshalers for OutputOptions by MIME type.
+	marshalerVariants map[string]*[numOutputOptions]Marshaler
+	// fieldsSelector is true if clients can request partial responses.
+	fieldsSelector bool
+	etag           bool
+	etagFunc       ETagFunc
+	logger         Logger
+	panicRecovery  bool
+	panicHandler   PanicHandlerFunc
+	// requestValidator validates requests in the generated handlers if not nil.
+	requestValidator RequestValidatorFunc
+	// trustedProxies is the networks of the proxies whose forwarding headers are trusted if not nil.
+	trustedProxies []*net.IPNet
+	// incomingMetadataAllowList is the keys of the metadata which can be taken from headers if not nil.
+	incomingMetadataAllowList metadataKeyList
+	incomingMetadataDenyList  metadataKeyList
+	incomingMetadataMaxCount  int
+	incomingMetadataMaxSize   int
+	incomingCookies           []cookieMapping
+	tracer                    Tracer
+	metrics                   *Metrics
+	// requestIDGenerator generates request IDs if not nil.
+	requestIDGenerator func() string
+	limiters           []*limiter
 }
 
 // ServeMuxOption is an option that can be given to a ServeMux on construction.
@@ -83,6 +114,7 @@ func WithOutgoingHeaderMatcher(fn HeaderMatcherFunc) ServeMuxOption {
 //
 // This can be used by services that need to read from http.Request and modify gRPC context. A common use case
 // is reading token from cookie and adding it in gRPC context.
+// The keys written by the annotator win over the same keys taken from the headers of the request.
 func WithMetadata(annotator func(context.Context, *http.Request) metadata.MD) ServeMuxOption {
 	return func(serveMux *ServeMux) {
 		serveMux.metadataAnnotators = append(serveMux.metadataAnnotators, annotator)
@@ -100,6 +132,30 @@ func WithProtoErrorHandler(fn ProtoErrorHandlerFunc) ServeMuxOption {
 	}
 }
 
+// WithStreamErrorHandler returns a ServeMuxOption representing a handler for errors in response streams.
+//
+// By default DefaultStreamErrorHandler is used. Use this option together with WithProtoErrorHandler
+// to make errors in response streams look the same as the other errors.
+func WithStreamErrorHandler(fn StreamErrorHandlerFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.streamErrorHandler = fn
+	}
+}
+
+// WithStatusMapping returns a ServeMuxOption which makes the ServeMux reply with "httpStatus" for errors of "code"
+// instead of the status returned by HTTPStatusFromCode, e.g. http.StatusBadRequest for codes.FailedPrecondition.
+//
+// It is respected by the default error handlers, by the errors in response streams and by the errors on routing.
+// Custom error handlers should use ServeMux.HTTPStatusFromCode to respect it.
+func WithStatusMapping(code codes.Code, httpStatus int) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		if serveMux.statusMapping == nil {
+			serveMux.statusMapping = make(map[codes.Code]int)
+		}
+		serveMux.statusMapping[code] = httpStatus
+	}
+}
+
 // NewServeMux returns a new ServeMux whose internal mapping is empty.
 func NewServeMux(opts ...ServeMuxOption) *ServeMux {
 	serveMux := &ServeMux{
@@ -124,6 +180,10 @@ func NewServeMux(opts ...ServeMuxOption) *ServeMux {
 		}
 	}
 
+	if serveMux.outputOptionsFromRequest {
+		serveMux.buildMarshalerVariants()
+	}
+
 	if serveMux.incomingHeaderMatcher == nil {
 		serveMux.incomingHeaderMatcher = DefaultHeaderMatcher
 	}
@@ -144,16 +204,35 @@ func (s *ServeMux) Handle(meth string, pat Pattern, h HandlerFunc) {
 
 // ServeHTTP dispatches the request to the first handler whose pattern matches to r.Method and r.Path.
 func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.requestIDGenerator != nil {
+		r = s.identifyRequest(w, r)
+	}
+	if s.panicRecovery {
+		rw := &recoveryResponseWriter{ResponseWriter: w}
+		defer s.recoverPanic(rw, r, cloneHeader(w.Header()))
+		w = exposeResponseWriter(rw, w)
+	}
 	ctx := r.Context()
+	var unmatched *rpcCall
+	if s.metrics != nil {
+		unmatched = &rpcCall{start: time.Now()}
+		defer func() {
+			if unmatched.st != nil {
+				s.metrics.observe(unmatched)
+			}
+		}()
+	}
 
 	path := r.URL.Path
 	if !strings.HasPrefix(path, "/") {
+		w = unmatchedResponseWriter(w, r, unmatched, codes.InvalidArgument)
 		if s.protoErrorHandler != nil {
 			_, outboundMarshaler := MarshalerForRequest(s, r)
 			sterr := status.Error(codes.InvalidArgument, http.StatusText(http.StatusBadRequest))
 			s.protoErrorHandler(ctx, s, outboundMarshaler, w, r, sterr)
 		} else {
-			OtherErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			code := s.HTTPStatusFromCode(codes.InvalidArgument)
+			OtherErrorHandler(w, r, http.StatusText(code), code)
 		}
 		return
 	}
@@ -162,6 +241,7 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	l := len(components)
 	var verb string
 	if idx := strings.LastIndex(components[l-1], ":"); idx == 0 {
+		w = unmatchedResponseWriter(w, r, unmatched, codes.Unimplemented)
 		if s.protoErrorHandler != nil {
 			_, outboundMarshaler := MarshalerForRequest(s, r)
 			sterr := status.Error(codes.Unimplemented, http.StatusText(http.StatusNotImplemented))
@@ -178,12 +258,13 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if override := r.Header.Get("X-HTTP-Method-Override"); override != "" && isPathLengthFallback(r) {
 		r.Method = strings.ToUpper(override)
 		if err := r.ParseForm(); err != nil {
+			w = unmatchedResponseWriter(w, r, unmatched, codes.InvalidArgument)
 			if s.protoErrorHandler != nil {
 				_, outboundMarshaler := MarshalerForRequest(s, r)
 				sterr := status.Error(codes.InvalidArgument, err.Error())
 				s.protoErrorHandler(ctx, s, outboundMarshaler, w, r, sterr)
 			} else {
-				OtherErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+				OtherErrorHandler(w, r, err.Error(), s.HTTPStatusFromCode(codes.InvalidArgument))
 			}
 			return
 		}
@@ -193,7 +274,7 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			continue
 		}
-		h.h(w, r, pathParams)
+		s.serveRoute(w, r, r.Method, h, pathParams)
 		return
 	}
 
@@ -211,18 +292,20 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			// X-HTTP-Method-Override is optional. Always allow fallback to POST.
 			if isPathLengthFallback(r) {
 				if err := r.ParseForm(); err != nil {
+					w = unmatchedResponseWriter(w, r, unmatched, codes.InvalidArgument)
 					if s.protoErrorHandler != nil {
 						_, outboundMarshaler := MarshalerForRequest(s, r)
 						sterr := status.Error(codes.InvalidArgument, err.Error())
 						s.protoErrorHandler(ctx, s, outboundMarshaler, w, r, sterr)
 					} else {
-						OtherErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+						OtherErrorHandler(w, r, err.Error(), s.HTTPStatusFromCode(codes.InvalidArgument))
 					}
 					return
 				}
-				h.h(w, r, pathParams)
+				s.serveRoute(w, r, m, h, pathParams)
 				return
 			}
+			w = unmatchedResponseWriter(w, r, unmatched, codes.Unimplemented)
 			if s.protoErrorHandler != nil {
 				_, outboundMarshaler := MarshalerForRequest(s, r)
 				sterr := status.Error(codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed))
@@ -234,6 +317,7 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	w = unmatchedResponseWriter(w, r, unmatched, codes.Unimplemented)
 	if s.protoErrorHandler != nil {
 		_, outboundMarshaler := MarshalerForRequest(s, r)
 		sterr := status.Error(codes.Unimplemented, http.StatusText(http.StatusNotImplemented))
@@ -243,6 +327,17 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HTTPStatusFromCode converts a gRPC error code into the corresponding HTTP response status
+// respecting the mapping given by WithStatusMapping.
+func (s *ServeMux) HTTPStatusFromCode(code codes.Code) int {
+	if s != nil {
+		if st, ok := s.statusMapping[code]; ok {
+			return st
+		}
+	}
+	return HTTPStatusFromCode(code)
+}
+
 // GetForwardResponseOptions returns the ForwardResponseOptions associated with this ServeMux.
 func (s *ServeMux) GetForwardResponseOptions() []func(context.Context, http.ResponseWriter, proto.Message) error {
 	return s.forwardResponseOptions
@@ -256,3 +351,96 @@ type handler struct {
 	pat Pattern
 	h   HandlerFunc
 }
+
+// serveRoute calls the handler of the route "h" for "meth" matched for "r".
+func (s *ServeMux) serveRoute(w http.ResponseWriter, r *http.Request, meth string, h handler, pathParams map[string]string) {
+	if s.tracer == nil && s.metrics == nil && len(s.limiters) == 0 {
+		h.h(w, r, pathParams)
+		return
+	}
+	call := &rpcCall{
+		span:  SpanInfo{HTTPMethod: meth, Pattern: h.pat.String()},
+		start: time.Now(),
+	}
+	r = r.WithContext(context.WithValue(r.Context(), rpcCallKey{}, call))
+	if s.metrics != nil {
+		if r.Body != nil {
+			r.Body = &metricsRequestBody{ReadCloser: r.Body, call: call}
+		}
+		w = exposeResponseWriter(&metricsResponseWriter{ResponseWriter: w, call: call}, w)
+	}
+	returned := false
+	defer func() {
+		if !returned {
+			call.setStatus(status.New(codes.Internal, http.StatusText(http.StatusInternalServerError)))
+		}
+		for _, release := range call.releases {
+			release()
+		}
+		s.endSpan(call)
+		s.metrics.observe(call)
+	}()
+	h.h(w, r, pathParams)
+	returned = true
+}
+
+// unmatchedResponseWriter records in "call" that "r" matches no route and fails with "code",
+// and returns "w" wrapped to count the bytes of the error response. "call" is nil unless metrics are collected.
+func unmatchedResponseWriter(w http.ResponseWriter, r *http.Request, call *rpcCall, code codes.Code) http.ResponseWriter {
+	if call == nil {
+		return w
+	}
+	call.span.HTTPMethod = r.Method
+	call.setStatus(status.New(code, ""))
+	return exposeResponseWriter(&metricsResponseWriter{ResponseWriter: w, call: call}, w)
+}
+
+// rpcCall records an RPC made by the gateway for a request.
+type rpcCall struct {
+	// The counters are updated atomically because request streams are sent in another goroutine.
+	requestBytes           int64
+	responseBytes          int64
+	streamMessagesSent     int64
+	streamMessagesReceived int64
+
+	span  SpanInfo
+	start time.Time
+	// spanCtx is the context returned by Tracer.StartSpan, if started.
+	spanCtx context.Context
+	st      *status.Status
+	// releases release the limits acquired for the request.
+	releases []func()
+}
+
+type rpcCallKey struct{}
+
+// rpcCallFromContext returns the rpcCall in "ctx" or in the context of "req", if any.
+func rpcCallFromContext(ctx context.Context, req *http.Request) *rpcCall {
+	if ctx == nil {
+		return nil
+	}
+	if call, ok := ctx.Value(rpcCallKey{}).(*rpcCall); ok {
+		return call
+	}
+	if req != nil {
+		if call, ok := req.Context().Value(rpcCallKey{}).(*rpcCall); ok {
+			return call
+		}
+	}
+	return nil
+}
+
+// setStatus records "st" as the status of the RPC. The first status wins.
+func (c *rpcCall) setStatus(st *status.Status) {
+	if c != nil && c.st == nil {
+		c.st = st
+	}
+}
+
+// status returns the status of the RPC, which is OK unless an error has been recorded.
+func (c *rpcCall) status() *status.Status {
+	if c.st == nil {
+		return status.New(codes.OK, "")
+	}
+	return c.st
+}
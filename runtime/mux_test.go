@@ -2,13 +2,17 @@ package runtime_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestMuxServeHTTP(t *testing.T) {
@@ -233,3 +237,42 @@ func TestMuxServeHTTP(t *testing.T) {
 		}
 	}
 }
+
+func TestServeMuxHTTPStatusFromCode(t *testing.T) {
+	mux := runtime.NewServeMux(
+		runtime.WithStatusMapping(codes.FailedPrecondition, http.StatusBadRequest),
+		runtime.WithStatusMapping(codes.Canceled, 499),
+	)
+	for _, spec := range []struct {
+		code codes.Code
+		want int
+	}{
+		{code: codes.FailedPrecondition, want: http.StatusBadRequest},
+		{code: codes.Canceled, want: 499},
+		{code: codes.NotFound, want: http.StatusNotFound},
+	} {
+		if got := mux.HTTPStatusFromCode(spec.code); got != spec.want {
+			t.Errorf("mux.HTTPStatusFromCode(%v) = %d; want %d", spec.code, got, spec.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	runtime.DefaultHTTPError(context.Background(), mux, &runtime.JSONBuiltin{}, w, httptest.NewRequest("GET", "/", nil), status.Error(codes.Canceled, "canceled"))
+	if got, want := w.Code, 499; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+}
+
+func TestMuxServeHTTPWithStatusMapping(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithStatusMapping(codes.InvalidArgument, http.StatusUnprocessableEntity))
+	r := httptest.NewRequest("GET", "http://host.example/", nil)
+	r.URL.Path = "foo"
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if got, want := w.Code, http.StatusUnprocessableEntity; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), http.StatusText(http.StatusUnprocessableEntity); got != want {
+		t.Errorf("w.Body = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OutputOptions are options of responses which clients can choose per request.
+type OutputOptions struct {
+	// Pretty requests human-readable output with indentation.
+	Pretty bool
+	// EnumsAsInts requests enum values to be rendered as numbers.
+	EnumsAsInts bool
+}
+
+// numOutputOptions is the number of the combinations of OutputOptions.
+const numOutputOptions = 4
+
+func (o OutputOptions) index() int {
+	var i int
+	if o.Pretty {
+		i |= 1
+	}
+	if o.EnumsAsInts {
+		i |= 2
+	}
+	return i
+}
+
+func outputOptionsFromIndex(i int) OutputOptions {
+	return OutputOptions{
+		Pretty:      i&1 != 0,
+		EnumsAsInts: i&2 != 0,
+	}
+}
+
+// OutputOptionsMarshaler is implemented by Marshalers which support OutputOptions.
+type OutputOptionsMarshaler interface {
+	Marshaler
+	// WithOutputOptions returns a Marshaler which behaves as the receiver with "opts" applied.
+	WithOutputOptions(opts OutputOptions) Marshaler
+}
+
+var (
+	_ OutputOptionsMarshaler = (*JSONPb)(nil)
+	_ OutputOptionsMarshaler = (*YAMLPb)(nil)
+)
+
+// WithOutputOptions returns a copy of "j" with "opts" applied.
+func (j *JSONPb) WithOutputOptions(opts OutputOptions) Marshaler {
+	m := *j
+	if opts.Pretty && m.Indent == "" {
+		m.Indent = "  "
+	}
+	if opts.EnumsAsInts {
+		m.EnumsAsInts = true
+	}
+	return &m
+}
+
+// WithOutputOptions returns a copy of "y" with "opts" applied.
+// Pretty has no effect since YAML is always human-readable.
+func (y *YAMLPb) WithOutputOptions(opts OutputOptions) Marshaler {
+	m := *y
+	if opts.EnumsAsInts {
+		m.EnumsAsInts = true
+	}
+	return &m
+}
+
+// WithOutputOptionsFromRequest returns a ServeMuxOption which lets clients choose OutputOptions per request.
+//
+// Clients can request them with the system parameters in query strings:
+//   - "$pretty" or "$pretty=true" for OutputOptions.Pretty
+//   - "$alt=json;enum-encoding=int" for OutputOptions.EnumsAsInts
+//
+// or with the parameters of the Accept header, e.g. "application/json; pretty=true; enums=int".
+//
+// Only the outbound Marshalers which implement OutputOptionsMarshaler are affected.
+// Their variants are derived when the ServeMux is created, so no Marshaler is allocated per request.
+func WithOutputOptionsFromRequest() ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.outputOptionsFromRequest = true
+	}
+}
+
+// buildMarshalerVariants derives the variants of the registered marshalers for all the combinations of OutputOptions.
+// The variants are keyed by the MIME types since Marshalers are not necessarily comparable.
+func (s *ServeMux) buildMarshalerVariants() {
+	s.marshalerVariants = make(map[string]*[numOutputOptions]Marshaler)
+	for mime, m := range s.marshalers.mimeMap {
+		om, ok := m.(OutputOptionsMarshaler)
+		if !ok {
+			continue
+		}
+		var variants [numOutputOptions]Marshaler
+		variants[0] = om
+		for i := 1; i < numOutputOptions; i++ {
+			variants[i] = om.WithOutputOptions(outputOptionsFromIndex(i))
+		}
+		s.marshalerVariants[mime] = &variants
+	}
+}
+
+// marshalerWithOutputOptions returns the variant of "m", which is registered for "mime", for the OutputOptions requested by "r".
+func (s *ServeMux) marshalerWithOutputOptions(r *http.Request, mime string, m Marshaler) Marshaler {
+	variants, ok := s.marshalerVariants[mime]
+	if !ok {
+		return m
+	}
+	return variants[outputOptionsFromRequest(r).index()]
+}
+
+func outputOptionsFromRequest(r *http.Request) OutputOptions {
+	var opts OutputOptions
+	if strings.Contains(r.URL.RawQuery, "$") {
+		// Parse the query by hand because url.ParseQuery rejects unescaped ";" in "$alt".
+		for _, kv := range strings.Split(r.URL.RawQuery, "&") {
+			k, v := kv, ""
+			if i := strings.IndexByte(kv, '='); i >= 0 {
+				k, v = kv[:i], kv[i+1:]
+			}
+			if !strings.HasPrefix(k, "$") {
+				continue
+			}
+			v, err := url.QueryUnescape(v)
+			if err != nil {
+				continue
+			}
+			switch k {
+			case "$pretty":
+				opts.Pretty = v == "" || isTrue(v)
+			case "$alt":
+				if _, params, err := mime.ParseMediaType(v); err == nil && params["enum-encoding"] == "int" {
+					opts.EnumsAsInts = true
+				}
+			}
+		}
+	}
+	for _, accept := range r.Header[acceptHeader] {
+		if !strings.Contains(accept, ";") {
+			continue
+		}
+		_, params, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+		if isTrue(params["pretty"]) {
+			opts.Pretty = true
+		}
+		if params["enums"] == "int" {
+			opts.EnumsAsInts = true
+		}
+	}
+	return opts
+}
+
+func isTrue(s string) bool {
+	b, err := strconv.ParseBool(s)
+	return err == nil && b
+}
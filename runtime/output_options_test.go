@@ -0,0 +1,133 @@
+package runtime_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+func TestMarshalerForRequestWithOutputOptions(t *testing.T) {
+	mux := runtime.NewServeMux(
+		runtime.WithOutputOptionsFromRequest(),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{OrigName: true}),
+		runtime.WithMarshalerOption("application/json", &runtime.JSONPb{OrigName: false}),
+		runtime.WithMarshalerOption("application/x-builtin", &runtime.JSONBuiltin{}),
+	)
+	for _, spec := range []struct {
+		target string
+		accept string
+
+		wantPretty      bool
+		wantEnumsAsInts bool
+		wantOrigName    bool
+	}{
+		{
+			target:       "/v1/example",
+			wantOrigName: true,
+		},
+		{
+			target:       "/v1/example?$pretty",
+			wantPretty:   true,
+			wantOrigName: true,
+		},
+		{
+			target:       "/v1/example?$pretty=false",
+			wantOrigName: true,
+		},
+		{
+			target:          "/v1/example?foo=bar&$alt=json;enum-encoding=int",
+			wantEnumsAsInts: true,
+			wantOrigName:    true,
+		},
+		{
+			target:          "/v1/example?$pretty=1&$alt=json%3Benum-encoding%3Dint",
+			wantPretty:      true,
+			wantEnumsAsInts: true,
+			wantOrigName:    true,
+		},
+		{
+			target:          "/v1/example",
+			accept:          "application/json; enums=int; pretty=true",
+			wantPretty:      true,
+			wantEnumsAsInts: true,
+		},
+		{
+			target: "/v1/example",
+			accept: "application/json",
+		},
+	} {
+		r := httptest.NewRequest("GET", spec.target, nil)
+		if spec.accept != "" {
+			r.Header.Set("Accept", spec.accept)
+		}
+		_, out := runtime.MarshalerForRequest(mux, r)
+		m, ok := out.(*runtime.JSONPb)
+		if !ok {
+			t.Errorf("out = %#v; want a *runtime.JSONPb; target=%q, accept=%q", out, spec.target, spec.accept)
+			continue
+		}
+		if got, want := m.Indent != "", spec.wantPretty; got != want {
+			t.Errorf("m.Indent = %q; want pretty=%v; target=%q, accept=%q", m.Indent, want, spec.target, spec.accept)
+		}
+		if got, want := m.EnumsAsInts, spec.wantEnumsAsInts; got != want {
+			t.Errorf("m.EnumsAsInts = %v; want %v; target=%q, accept=%q", got, want, spec.target, spec.accept)
+		}
+		if got, want := m.OrigName, spec.wantOrigName; got != want {
+			t.Errorf("m.OrigName = %v; want %v; target=%q, accept=%q", got, want, spec.target, spec.accept)
+		}
+
+		// The variants are shared among requests.
+		if _, again := runtime.MarshalerForRequest(mux, r); again != out {
+			t.Errorf("runtime.MarshalerForRequest returned a different marshaler for the same request; target=%q, accept=%q", spec.target, spec.accept)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/v1/example?$pretty", nil)
+	r.Header.Set("Accept", "application/x-builtin")
+	if _, out := runtime.MarshalerForRequest(mux, r); out == nil {
+		t.Errorf("out = nil; want the registered marshaler")
+	} else if _, ok := out.(*runtime.JSONBuiltin); !ok {
+		t.Errorf("out = %#v; want a *runtime.JSONBuiltin", out)
+	}
+}
+
+func TestMarshalerForRequestWithoutOutputOptions(t *testing.T) {
+	mux := runtime.NewServeMux()
+	r, err := http.NewRequest("GET", "http://example.com/v1/example?$pretty", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest failed with %v; want success", err)
+	}
+	_, out := runtime.MarshalerForRequest(mux, r)
+	if m, ok := out.(*runtime.JSONPb); !ok || m.Indent != "" {
+		t.Errorf("out = %#v; want the default marshaler as it is", out)
+	}
+}
+
+// uncomparableMarshaler is an OutputOptionsMarshaler which cannot be a map key.
+type uncomparableMarshaler struct {
+	*runtime.JSONBuiltin
+	opts []runtime.OutputOptions
+}
+
+func (m uncomparableMarshaler) WithOutputOptions(opts runtime.OutputOptions) runtime.Marshaler {
+	return uncomparableMarshaler{JSONBuiltin: m.JSONBuiltin, opts: append(m.opts, opts)}
+}
+
+func TestMarshalerForRequestWithUncomparableMarshaler(t *testing.T) {
+	mux := runtime.NewServeMux(
+		runtime.WithOutputOptionsFromRequest(),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, uncomparableMarshaler{JSONBuiltin: &runtime.JSONBuiltin{}}),
+	)
+	r := httptest.NewRequest("GET", "/v1/example?$pretty", nil)
+	_, out := runtime.MarshalerForRequest(mux, r)
+	m, ok := out.(uncomparableMarshaler)
+	if !ok {
+		t.Fatalf("out = %#v; want an uncomparableMarshaler", out)
+	}
+	if want := []runtime.OutputOptions{{Pretty: true}}; !reflect.DeepEqual(m.opts, want) {
+		t.Errorf("m.opts = %+v; want %+v", m.opts, want)
+	}
+}
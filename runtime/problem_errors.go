@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProblemContentType is the media type of the problem details defined in RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeFunc derives the "type" URI and the "title" of a problem details object from a gRPC status.
+// "details" is the result of the error detail handlers applied to the status.
+type ProblemTypeFunc func(ctx context.Context, s *status.Status, details *HTTPErrorDetails) (typeURI, title string)
+
+// DefaultProblemType is the default implementation of ProblemTypeFunc.
+// It returns "about:blank" and the status text of the HTTP status code as recommended by RFC 7807.
+func DefaultProblemType(_ context.Context, _ *status.Status, details *HTTPErrorDetails) (string, string) {
+	return "about:blank", http.StatusText(details.Status)
+}
+
+// problemDetails is a problem details object defined in RFC 7807.
+type problemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members.
+	Code            codes.Code                              `json:"code"`
+	Domain          string                                  `json:"domain,omitempty"`
+	Reason          string                                  `json:"reason,omitempty"`
+	FieldViolations []*errdetails.BadRequest_FieldViolation `json:"fieldViolations,omitempty"`
+	RequestID       string                                  `json:"requestId,omitempty"`
+}
+
+// NewProblemErrorHandler returns a ProtoErrorHandlerFunc which replies with a problem details object
+// defined in RFC 7807 as application/problem+json regardless of the Marshaler.
+// "typeFunc" derives the "type" and "title" members. DefaultProblemType is used if it is nil.
+//
+// Besides the members defined in the RFC, the object contains the gRPC status code as "code",
+// and "domain", "reason", "fieldViolations" and "requestId" as derived by the error detail handlers.
+// Headers and trailers are forwarded in the same way as DefaultHTTPProtoErrorHandler.
+//
+// Use it with WithProtoErrorHandler option.
+func NewProblemErrorHandler(typeFunc ProblemTypeFunc) ProtoErrorHandlerFunc {
+	if typeFunc == nil {
+		typeFunc = DefaultProblemType
+	}
+	return func(ctx context.Context, mux *ServeMux, _ Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+		const fallback = `{"type": "about:blank", "title": "Internal Server Error", "status": 500, "code": 13}`
+
+		w.Header().Del("Trailer")
+		w.Header().Set("Content-Type", ProblemContentType)
+
+		s, ok := status.FromError(err)
+		if !ok {
+			s = status.New(codes.Unknown, err.Error())
+		}
+		s = statusWithRequestID(ctx, s)
+
+		details := HTTPErrorDetailsFromStatus(ctx, mux, s)
+		body := &problemDetails{
+			Status:          details.Status,
+			Detail:          s.Message(),
+			Code:            s.Code(),
+			Domain:          details.Domain,
+			Reason:          details.Reason,
+			FieldViolations: details.FieldViolations,
+			RequestID:       details.RequestID,
+		}
+		body.Type, body.Title = typeFunc(ctx, s, details)
+		if r != nil && r.URL != nil {
+			body.Instance = r.URL.Path
+		}
+
+		buf, merr := json.Marshal(body)
+		if merr != nil {
+			mux.Logger().Errorf(ctx, "Failed to marshal error message %q: %v", proto.CompactTextString(s.Proto()), merr)
+			w.WriteHeader(http.StatusInternalServerError)
+			if _, err := io.WriteString(w, fallback); err != nil {
+				mux.Logger().Infof(ctx, "Failed to write response: %v", err)
+			}
+			return
+		}
+
+		md, ok := ServerMetadataFromContext(ctx)
+		if !ok {
+			mux.Logger().Errorf(ctx, "Failed to extract ServerMetadata from context")
+		}
+
+		ForwardResponseServerMetadata(ctx, w, mux, md)
+		ForwardResponseTrailerHeader(w, mux, md)
+		for k, vs := range details.Header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(details.Status)
+		if _, err := w.Write(buf); err != nil {
+			mux.Logger().Infof(ctx, "Failed to write response: %v", err)
+		}
+
+		ForwardResponseTrailer(w, mux, md)
+	}
+}
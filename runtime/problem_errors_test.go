@@ -0,0 +1,113 @@
+package runtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestProblemErrorHandler(t *testing.T) {
+	withInfo, _ := status.New(codes.PermissionDenied, "api disabled").WithDetails(&errdetails.ErrorInfo{
+		Domain: "example.com",
+		Reason: "API_DISABLED",
+	})
+	typeFromInfo := func(_ context.Context, s *status.Status, details *runtime.HTTPErrorDetails) (string, string) {
+		if details.Reason == "" {
+			return runtime.DefaultProblemType(context.Background(), s, details)
+		}
+		return fmt.Sprintf("https://%s/problems/%s", details.Domain, details.Reason), details.Reason
+	}
+
+	for _, spec := range []struct {
+		name     string
+		typeFunc runtime.ProblemTypeFunc
+		err      error
+		want     map[string]interface{}
+	}{
+		{
+			name: "default",
+			err:  status.Error(codes.NotFound, "no such resource"),
+			want: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Not Found",
+				"status":   float64(http.StatusNotFound),
+				"detail":   "no such resource",
+				"instance": "/v1/resources/1",
+				"code":     float64(codes.NotFound),
+			},
+		},
+		{
+			name: "non-gRPC error",
+			err:  fmt.Errorf("example error"),
+			want: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Internal Server Error",
+				"status":   float64(http.StatusInternalServerError),
+				"detail":   "example error",
+				"instance": "/v1/resources/1",
+				"code":     float64(codes.Unknown),
+			},
+		},
+		{
+			name:     "type from error info",
+			typeFunc: typeFromInfo,
+			err:      withInfo.Err(),
+			want: map[string]interface{}{
+				"type":     "https://example.com/problems/API_DISABLED",
+				"title":    "API_DISABLED",
+				"status":   float64(http.StatusForbidden),
+				"detail":   "api disabled",
+				"instance": "/v1/resources/1",
+				"code":     float64(codes.PermissionDenied),
+				"domain":   "example.com",
+				"reason":   "API_DISABLED",
+			},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{
+				HeaderMD:  metadata.Pairs("foo", "bar"),
+				TrailerMD: metadata.Pairs("baz", "qux"),
+			})
+			mux := runtime.NewServeMux()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/v1/resources/1?secret=xyz", nil)
+			runtime.NewProblemErrorHandler(spec.typeFunc)(ctx, mux, &runtime.JSONPb{}, w, req, spec.err)
+
+			if got, want := w.Header().Get("Content-Type"), runtime.ProblemContentType; got != want {
+				t.Errorf(`w.Header().Get("Content-Type") = %q; want %q`, got, want)
+			}
+			if got, want := w.Code, int(spec.want["status"].(float64)); got != want {
+				t.Errorf("w.Code = %d; want %d", got, want)
+			}
+			if got, want := w.Header().Get("Grpc-Metadata-Foo"), "bar"; got != want {
+				t.Errorf(`w.Header().Get("Grpc-Metadata-Foo") = %q; want %q`, got, want)
+			}
+			if got, want := w.Header().Get("Grpc-Trailer-Baz"), "qux"; got != want {
+				t.Errorf(`w.Header().Get("Grpc-Trailer-Baz") = %q; want %q`, got, want)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal(%q, &got) failed with %v; want success", w.Body.Bytes(), err)
+			}
+			if len(got) != len(spec.want) {
+				t.Errorf("body = %v; want %v", got, spec.want)
+			}
+			for k, want := range spec.want {
+				if got[k] != want {
+					t.Errorf("body[%q] = %v; want %v", k, got[k], want)
+				}
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@ import (
 
 	"context"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
 )
 
@@ -16,10 +15,13 @@ type ProtoErrorHandlerFunc func(context.Context, *ServeMux, Marshaler, http.Resp
 var _ ProtoErrorHandlerFunc = DefaultHTTPProtoErrorHandler
 
 // DefaultHTTPProtoErrorHandler is an implementation of HTTPError.
-// If "err" is an error from gRPC system, the function replies with the status code mapped by HTTPStatusFromCode.
+// If "err" is an error from gRPC system, the function replies with the status code mapped by ServeMux.HTTPStatusFromCode.
 // If otherwise, it replies with http.StatusInternalServerError.
 //
 // The response body returned by this function is a Status message marshaled by a Marshaler.
+// The details of the status are reflected on the status code and headers of the response
+// by the handlers registered with WithErrorDetailHandler.
+// The ID of the request given by WithRequestID is added to the details as google.rpc.RequestInfo.
 //
 // Do not set this function to HTTPError variable directly, use WithProtoErrorHandler option instead.
 func DefaultHTTPProtoErrorHandler(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
@@ -33,29 +35,35 @@ func DefaultHTTPProtoErrorHandler(ctx context.Context, mux *ServeMux, marshaler
 	if !ok {
 		s = status.New(codes.Unknown, err.Error())
 	}
+	s = statusWithRequestID(ctx, s)
 
 	buf, merr := marshaler.Marshal(s.Proto())
 	if merr != nil {
-		grpclog.Infof("Failed to marshal error message %q: %v", s.Proto(), merr)
+		mux.Logger().Errorf(ctx, "Failed to marshal error message %q: %v", s.Proto(), merr)
 		w.WriteHeader(http.StatusInternalServerError)
 		if _, err := io.WriteString(w, fallback); err != nil {
-			grpclog.Infof("Failed to write response: %v", err)
+			mux.Logger().Infof(ctx, "Failed to write response: %v", err)
 		}
 		return
 	}
 
 	md, ok := ServerMetadataFromContext(ctx)
 	if !ok {
-		grpclog.Infof("Failed to extract ServerMetadata from context")
+		mux.Logger().Errorf(ctx, "Failed to extract ServerMetadata from context")
 	}
 
-	handleForwardResponseServerMetadata(w, mux, md)
-	handleForwardResponseTrailerHeader(w, md)
-	st := HTTPStatusFromCode(s.Code())
-	w.WriteHeader(st)
+	ForwardResponseServerMetadata(ctx, w, mux, md)
+	ForwardResponseTrailerHeader(w, mux, md)
+	details := HTTPErrorDetailsFromStatus(ctx, mux, s)
+	for k, vs := range details.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(details.Status)
 	if _, err := w.Write(buf); err != nil {
-		grpclog.Infof("Failed to write response: %v", err)
+		mux.Logger().Infof(ctx, "Failed to write response: %v", err)
 	}
 
-	handleForwardResponseTrailer(w, md)
+	ForwardResponseTrailer(w, mux, md)
 }
@@ -1,38 +1,46 @@
 package runtime
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/url"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/grpc-ecosystem/grpc-gateway/utilities"
-	"google.golang.org/grpc/grpclog"
 )
 
 // PopulateQueryParameters populates "values" into "msg".
 // A value is ignored if its key starts with one of the elements in "filter".
 func PopulateQueryParameters(msg proto.Message, values url.Values, filter *utilities.DoubleArray) error {
+	return ForEachQueryParameter(values, filter, func(fieldPath []string, values []string) error {
+		return populateFieldValueFromPath(context.Background(), msg, fieldPath, values)
+	})
+}
+
+// ForEachQueryParameter calls "fn" with the field path and the values of each query parameter in "values".
+// A parameter is skipped if its key starts with one of the elements in "filter".
+// A key of the form "name[key]" is passed as the field path "name" with "key" prepended to the values,
+// which is how map entries are given in query parameters.
+//
+// Code generated by protoc-gen-grpc-gateway uses this together with a typed populator for the request message
+// instead of the reflection-based PopulateQueryParameters.
+func ForEachQueryParameter(values url.Values, filter *utilities.DoubleArray, fn func(fieldPath []string, values []string) error) error {
 	for key, values := range values {
-		re, err := regexp.Compile("^(.*)\\[(.*)\\]$")
-		if err != nil {
-			return err
-		}
-		match := re.FindStringSubmatch(key)
-		if len(match) == 3 {
-			key = match[1]
-			values = append([]string{match[2]}, values...)
+		if l := len(key); l > 0 && key[l-1] == ']' {
+			if idx := strings.LastIndexByte(key, '['); idx >= 0 {
+				key, values = key[:idx], append([]string{key[idx+1 : l-1]}, values...)
+			}
 		}
 		fieldPath := strings.Split(key, ".")
 		if filter.HasCommonPrefix(fieldPath) {
 			continue
 		}
-		if err := populateFieldValueFromPath(msg, fieldPath, values); err != nil {
+		if err := fn(fieldPath, values); err != nil {
 			return err
 		}
 	}
@@ -43,10 +51,18 @@ func PopulateQueryParameters(msg proto.Message, values url.Values, filter *utili
 // It instantiates missing protobuf fields as it goes.
 func PopulateFieldFromPath(msg proto.Message, fieldPathString string, value string) error {
 	fieldPath := strings.Split(fieldPathString, ".")
-	return populateFieldValueFromPath(msg, fieldPath, []string{value})
+	return populateFieldValueFromPath(context.Background(), msg, fieldPath, []string{value})
+}
+
+// PopulateFieldValuesFromPath sets "values" to the field at "fieldPath" in "msg" in the same way as
+// PopulateQueryParameters does for a single query parameter.
+// Generated query parameter populators fall back to this function for fields they do not handle themselves.
+// Unknown fields are logged with the Logger in "ctx".
+func PopulateFieldValuesFromPath(ctx context.Context, msg proto.Message, fieldPath []string, values []string) error {
+	return populateFieldValueFromPath(ctx, msg, fieldPath, values)
 }
 
-func populateFieldValueFromPath(msg proto.Message, fieldPath []string, values []string) error {
+func populateFieldValueFromPath(ctx context.Context, msg proto.Message, fieldPath []string, values []string) error {
 	m := reflect.ValueOf(msg)
 	if m.Kind() != reflect.Ptr {
 		return fmt.Errorf("unexpected type %T: %v", msg, msg)
@@ -64,7 +80,7 @@ func populateFieldValueFromPath(msg proto.Message, fieldPath []string, values []
 		if err != nil {
 			return err
 		} else if !f.IsValid() {
-			grpclog.Infof("field not found in %T: %s", msg, strings.Join(fieldPath, "."))
+			LoggerFromContext(ctx).Infof(ctx, "field not found in %T: %s", msg, strings.Join(fieldPath, "."))
 			return nil
 		}
 
@@ -108,7 +124,7 @@ func populateFieldValueFromPath(msg proto.Message, fieldPath []string, values []
 		return fmt.Errorf("no value of field: %s", strings.Join(fieldPath, "."))
 	case 1:
 	default:
-		grpclog.Infof("too many field values: %s", strings.Join(fieldPath, "."))
+		LoggerFromContext(ctx).Infof(ctx, "too many field values: %s", strings.Join(fieldPath, "."))
 	}
 	return populateField(m, values[0], props)
 }
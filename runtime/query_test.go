@@ -1,10 +1,12 @@
 package runtime_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -575,6 +577,85 @@ func TestPopulateQueryParametersWithInvalidNestedParameters(t *testing.T) {
 	}
 }
 
+func TestForEachQueryParameter(t *testing.T) {
+	type param struct {
+		fieldPath string
+		values    []string
+	}
+	for _, spec := range []struct {
+		values url.Values
+		filter *utilities.DoubleArray
+		want   []param
+	}{
+		{
+			values: url.Values{
+				"float_value":    {"1.5"},
+				"nested.string":  {"a", "b"},
+				"map_value[key]": {"value"},
+			},
+			filter: utilities.NewDoubleArray(nil),
+			want: []param{
+				{fieldPath: "float_value", values: []string{"1.5"}},
+				{fieldPath: "nested.string", values: []string{"a", "b"}},
+				{fieldPath: "map_value", values: []string{"key", "value"}},
+			},
+		},
+		{
+			values: url.Values{
+				"nested.string":     {"a"},
+				"nested_non_null.b": {"b"},
+				"bool_value":        {"true"},
+			},
+			filter: utilities.NewDoubleArray([][]string{{"nested"}, {"bool_value"}}),
+			want: []param{
+				{fieldPath: "nested_non_null.b", values: []string{"b"}},
+			},
+		},
+	} {
+		got := make(map[string][]string)
+		err := runtime.ForEachQueryParameter(spec.values, spec.filter, func(fieldPath, values []string) error {
+			got[strings.Join(fieldPath, ".")] = values
+			return nil
+		})
+		if err != nil {
+			t.Errorf("runtime.ForEachQueryParameter(%v, %v, fn) failed with %v; want success", spec.values, spec.filter, err)
+			continue
+		}
+		want := make(map[string][]string)
+		for _, p := range spec.want {
+			want[p.fieldPath] = p.values
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("runtime.ForEachQueryParameter(%v, %v, fn) called fn with %v; want %v", spec.values, spec.filter, got, want)
+		}
+	}
+}
+
+func TestForEachQueryParameterError(t *testing.T) {
+	wantErr := errors.New("populate failed")
+	values := url.Values{"float_value": {"1.5"}}
+	err := runtime.ForEachQueryParameter(values, utilities.NewDoubleArray(nil), func(fieldPath, values []string) error {
+		return wantErr
+	})
+	if err != wantErr {
+		t.Errorf("runtime.ForEachQueryParameter(%v, filter, fn) failed with %v; want %v", values, err, wantErr)
+	}
+}
+
+func TestPopulateFieldValuesFromPath(t *testing.T) {
+	msg := new(proto3Message)
+	if err := runtime.PopulateFieldValuesFromPath(context.Background(), msg, []string{"repeated_value"}, []string{"a", "b"}); err != nil {
+		t.Fatalf("runtime.PopulateFieldValuesFromPath(context.Background(), msg, %q, %q) failed with %v; want success", "repeated_value", []string{"a", "b"}, err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(msg.RepeatedValue, want) {
+		t.Errorf("msg.RepeatedValue = %q; want %q", msg.RepeatedValue, want)
+	}
+
+	if err := runtime.PopulateFieldValuesFromPath(context.Background(), msg, []string{"float_value", "nested"}, []string{"1"}); err == nil {
+		t.Errorf("runtime.PopulateFieldValuesFromPath(context.Background(), msg, %q, %q) did not fail; want error", "float_value.nested", []string{"1"})
+	}
+}
+
 type proto3Message struct {
 	Nested             *proto2Message           `protobuf:"bytes,1,opt,name=nested,json=nested" json:"nested,omitempty"`
 	NestedNonNull      proto2Message            `protobuf:"bytes,15,opt,name=nested_non_null,json=nestedNonNull" json:"nested_non_null,omitempty"`
@@ -0,0 +1,187 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PanicHandlerFunc is called with the value and the stack trace of a panic recovered by ServeMux.
+type PanicHandlerFunc func(ctx context.Context, r *http.Request, p interface{}, stack []byte)
+
+// WithPanicRecovery returns a ServeMuxOption which makes ServeMux recover panics in the handlers,
+// e.g. in ForwardResponseOptions, metadata annotators or marshalers.
+//
+// "fn" is called with the stack trace of the panic. If it is nil, the stack trace is logged with the Logger of the ServeMux.
+// Then the client gets an Internal status through HTTPError, unless the handler has already written a part of the response.
+// In that case, the response is aborted so that the client does not take the partial response for a complete one.
+func WithPanicRecovery(fn PanicHandlerFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.panicRecovery = true
+		serveMux.panicHandler = fn
+	}
+}
+
+// recoverPanic recovers a panic in the handler which has been writing into "w".
+// "header" is the header of the response before the handler was called.
+// It must be called directly by a deferred function call.
+func (s *ServeMux) recoverPanic(w *recoveryResponseWriter, r *http.Request, header http.Header) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if p == http.ErrAbortHandler {
+		panic(p)
+	}
+
+	ctx := r.Context()
+	stack := debug.Stack()
+	if s.panicHandler != nil {
+		s.panicHandler(ctx, r, p, stack)
+	} else {
+		s.Logger().Errorf(ctx, "panic in handler for %s %s: %v\n%s", r.Method, r.URL.Path, p, stack)
+	}
+
+	if w.wroteHeader {
+		panic(http.ErrAbortHandler)
+	}
+	// Discard the header set by the handler.
+	h := w.ResponseWriter.Header()
+	for k := range h {
+		delete(h, k)
+	}
+	for k, v := range header {
+		h[k] = v
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			s.Logger().Errorf(ctx, "panic in error handler: %v", p)
+			panic(http.ErrAbortHandler)
+		}
+	}()
+	_, outboundMarshaler := MarshalerForRequest(s, r)
+	ctx = NewServerMetadataContext(ctx, ServerMetadata{})
+	HTTPError(ctx, s, outboundMarshaler, w.ResponseWriter, r, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError)))
+}
+
+// recoveryResponseWriter records whether the header of the response has been sent.
+type recoveryResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoveryResponseWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recoveryResponseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher for ForwardResponseStream.
+func (w *recoveryResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// CloseNotify implements http.CloseNotifier if the underlying http.ResponseWriter does.
+func (w *recoveryResponseWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// Hijack implements http.Hijacker if the underlying http.ResponseWriter does.
+// The error response is not sent after the connection is hijacked.
+func (w *recoveryResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Push implements http.Pusher if the underlying http.ResponseWriter does.
+func (w *recoveryResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// responseWriterWrapper is an http.ResponseWriter which wraps another one and forwards the optional interfaces to it.
+// The methods of the optional interfaces may be called only if the wrapped http.ResponseWriter implements them.
+type responseWriterWrapper interface {
+	http.ResponseWriter
+	http.Flusher
+	http.CloseNotifier
+	http.Hijacker
+	http.Pusher
+}
+
+// exposeResponseWriter returns "wrapper" as an http.ResponseWriter which implements http.CloseNotifier,
+// http.Hijacker and http.Pusher only if "w", which "wrapper" wraps, implements them,
+// so that wrapping "w" does not change what handlers can do with it.
+// It implements http.Flusher for ForwardResponseStream in any case.
+func exposeResponseWriter(wrapper responseWriterWrapper, w http.ResponseWriter) http.ResponseWriter {
+	type flushWriter interface {
+		http.ResponseWriter
+		http.Flusher
+	}
+	_, cn := w.(http.CloseNotifier)
+	_, hj := w.(http.Hijacker)
+	_, pu := w.(http.Pusher)
+	switch {
+	case cn && hj && pu:
+		return struct {
+			flushWriter
+			http.CloseNotifier
+			http.Hijacker
+			http.Pusher
+		}{wrapper, wrapper, wrapper, wrapper}
+	case cn && hj:
+		return struct {
+			flushWriter
+			http.CloseNotifier
+			http.Hijacker
+		}{wrapper, wrapper, wrapper}
+	case cn && pu:
+		return struct {
+			flushWriter
+			http.CloseNotifier
+			http.Pusher
+		}{wrapper, wrapper, wrapper}
+	case hj && pu:
+		return struct {
+			flushWriter
+			http.Hijacker
+			http.Pusher
+		}{wrapper, wrapper, wrapper}
+	case cn:
+		return struct {
+			flushWriter
+			http.CloseNotifier
+		}{wrapper, wrapper}
+	case hj:
+		return struct {
+			flushWriter
+			http.Hijacker
+		}{wrapper, wrapper}
+	case pu:
+		return struct {
+			flushWriter
+			http.Pusher
+		}{wrapper, wrapper}
+	}
+	return struct{ flushWriter }{wrapper}
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
@@ -0,0 +1,151 @@
+package runtime_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+	"google.golang.org/grpc/codes"
+)
+
+func newPanickingMux(t *testing.T, handler runtime.HandlerFunc, opts ...runtime.ServeMuxOption) *runtime.ServeMux {
+	pat, err := runtime.NewPattern(1, []int{int(utilities.OpLitPush), 0}, []string{"foo"}, "")
+	if err != nil {
+		t.Fatalf("runtime.NewPattern failed with %v; want success", err)
+	}
+	mux := runtime.NewServeMux(opts...)
+	mux.Handle("GET", pat, handler)
+	return mux
+}
+
+func TestServeMuxWithPanicRecovery(t *testing.T) {
+	var (
+		recovered interface{}
+		stack     []byte
+	)
+	hook := func(ctx context.Context, r *http.Request, p interface{}, s []byte) {
+		recovered, stack = p, s
+	}
+	mux := newPanickingMux(t, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		w.Header().Set("Grpc-Metadata-Foo", "bar")
+		panic("boom")
+	}, runtime.WithPanicRecovery(hook), runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONBuiltin{}))
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	w := httptest.NewRecorder()
+	w.Header().Set("X-Middleware", "baz")
+	mux.ServeHTTP(w, req)
+
+	if recovered != "boom" {
+		t.Errorf("recovered = %v; want %q", recovered, "boom")
+	}
+	if len(stack) == 0 {
+		t.Errorf("stack is empty; want the stack trace of the panic")
+	}
+	if got, want := w.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+	if got := w.Header().Get("Grpc-Metadata-Foo"); got != "" {
+		t.Errorf("Grpc-Metadata-Foo = %q; want the header of the handler to be discarded", got)
+	}
+	if got, want := w.Header().Get("X-Middleware"), "baz"; got != want {
+		t.Errorf("X-Middleware = %q; want %q", got, want)
+	}
+	var body struct {
+		Code  int    `json:"code"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("json.Unmarshal(%q, &body) failed with %v; want success", w.Body.String(), err)
+	}
+	if got, want := codes.Code(body.Code), codes.Internal; got != want {
+		t.Errorf("body.Code = %v; want %v", got, want)
+	}
+	if body.Error == "boom" {
+		t.Errorf("body.Error = %q; want the panic not to be leaked", body.Error)
+	}
+}
+
+func TestServeMuxWithPanicRecoveryAbortsPartialResponse(t *testing.T) {
+	var called bool
+	mux := newPanickingMux(t, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		w.Write([]byte(`{"result":`))
+		panic("boom")
+	}, runtime.WithPanicRecovery(func(ctx context.Context, r *http.Request, p interface{}, stack []byte) {
+		called = true
+	}))
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	w := httptest.NewRecorder()
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recover() = %v; want %v", p, http.ErrAbortHandler)
+		}
+		if !called {
+			t.Errorf("the panic handler was not called")
+		}
+		if got, want := w.Body.String(), `{"result":`; got != want {
+			t.Errorf("w.Body = %q; want %q", got, want)
+		}
+	}()
+	mux.ServeHTTP(w, req)
+}
+
+func TestServeMuxWithoutPanicRecovery(t *testing.T) {
+	mux := newPanickingMux(t, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	w := httptest.NewRecorder()
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recover() = %v; want %q", p, "boom")
+		}
+	}()
+	mux.ServeHTTP(w, req)
+}
+
+// hijackableRecorder is an httptest.ResponseRecorder which implements http.Hijacker.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestServeMuxWithPanicRecoveryKeepsResponseWriterInterfaces(t *testing.T) {
+	var (
+		hijacker      bool
+		closeNotifier bool
+		pusher        bool
+	)
+	mux := newPanickingMux(t, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		var h http.Hijacker
+		h, hijacker = w.(http.Hijacker)
+		_, closeNotifier = w.(http.CloseNotifier)
+		_, pusher = w.(http.Pusher)
+		if hijacker {
+			h.Hijack()
+		}
+	}, runtime.WithPanicRecovery(nil))
+
+	w := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+	if !hijacker || !w.hijacked {
+		t.Errorf("hijacker = %v, w.hijacked = %v; want the underlying http.Hijacker to be available", hijacker, w.hijacked)
+	}
+	if closeNotifier || pusher {
+		t.Errorf("closeNotifier = %v, pusher = %v; want false as the underlying http.ResponseWriter does not implement them", closeNotifier, pusher)
+	}
+}
@@ -0,0 +1,109 @@
+package runtime
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the header of requests and responses which has the request ID.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength is the maximum length of request IDs taken from requests.
+const maxRequestIDLength = 128
+
+// WithRequestID returns a ServeMuxOption which makes ServeMux identify each request with an ID.
+//
+// The ID is taken from the X-Request-Id header of the request, or generated by "generate" if the header
+// is missing or invalid, i.e. longer than 128 bytes or not printable ASCII. Random UUIDs are generated if
+// "generate" is nil.
+// The ID is set to the X-Request-Id header of the response, forwarded to the gRPC server as the metadata "x-request-id",
+// and available with RequestIDFromContext. The default error handlers add it to the details of the status
+// as google.rpc.RequestInfo, unless the gRPC server has added one.
+func WithRequestID(generate func() string) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		if generate == nil {
+			generate = newRandomRequestID
+		}
+		serveMux.requestIDGenerator = generate
+	}
+}
+
+type requestIDKey struct{}
+
+// NewRequestIDContext returns a new context which carries the request ID "id".
+func NewRequestIDContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID in "ctx", if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// identifyRequest returns "r" with the request ID in its context and sets the ID to the header of "w".
+func (s *ServeMux) identifyRequest(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(RequestIDHeader)
+	if !isValidRequestID(id) {
+		id = s.requestIDGenerator()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return r.WithContext(NewRequestIDContext(r.Context(), id))
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRandomRequestID returns a random (version 4) UUID.
+func newRandomRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		defaultLogger.Errorf(context.Background(), "Failed to generate a request ID: %v", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// statusWithRequestID returns "s" with the request ID in "ctx" as google.rpc.RequestInfo in the details,
+// unless "s" has one.
+func statusWithRequestID(ctx context.Context, s *status.Status) *status.Status {
+	id, ok := RequestIDFromContext(ctx)
+	if !ok {
+		return s
+	}
+	name := proto.MessageName(&errdetails.RequestInfo{})
+	for _, d := range s.Proto().GetDetails() {
+		if strings.HasSuffix(d.GetTypeUrl(), "/"+name) {
+			return s
+		}
+	}
+	withID, err := s.WithDetails(&errdetails.RequestInfo{RequestId: id})
+	if err != nil {
+		return s
+	}
+	return withID
+}
+
+func handleRequestInfo(_ context.Context, detail proto.Message, resp *HTTPErrorDetails) {
+	resp.RequestID = detail.(*errdetails.RequestInfo).GetRequestId()
+}
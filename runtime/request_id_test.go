@@ -0,0 +1,134 @@
+package runtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestServeMuxWithRequestID(t *testing.T) {
+	pat, err := runtime.NewPattern(1, []int{int(utilities.OpLitPush), 0}, []string{"foo"}, "")
+	if err != nil {
+		t.Fatalf("runtime.NewPattern failed with %v; want success", err)
+	}
+	for _, spec := range []struct {
+		name     string
+		header   string
+		generate func() string
+		want     string
+	}{
+		{name: "from request", header: "abc-123", want: "abc-123"},
+		{name: "generated", generate: func() string { return "generated" }, want: "generated"},
+		{name: "invalid", header: "abc 123", generate: func() string { return "generated" }, want: "generated"},
+		{name: "too long", header: strings.Repeat("a", 129), generate: func() string { return "generated" }, want: "generated"},
+		{name: "random"},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithRequestID(spec.generate))
+			var (
+				md    metadata.MD
+				ctxID string
+			)
+			mux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				ctx, err := runtime.AnnotateContext(context.Background(), mux, r)
+				if err != nil {
+					t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+				}
+				md, _ = metadata.FromOutgoingContext(ctx)
+				ctxID, _ = runtime.RequestIDFromContext(ctx)
+				runtime.DefaultHTTPProtoErrorHandler(ctx, mux, &runtime.JSONBuiltin{}, w, r, status.Error(codes.NotFound, "not found"))
+			})
+
+			req, err := http.NewRequest("GET", "http://example.com/foo", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest failed with %v; want success", err)
+			}
+			if spec.header != "" {
+				req.Header.Set("X-Request-Id", spec.header)
+			}
+			req.Header.Set("Grpc-Metadata-X-Request-Id", "spoofed")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			id := w.Header().Get("X-Request-Id")
+			if spec.want != "" && id != spec.want {
+				t.Errorf(`w.Header().Get("X-Request-Id") = %q; want %q`, id, spec.want)
+			}
+			if spec.want == "" && !uuidPattern.MatchString(id) {
+				t.Errorf(`w.Header().Get("X-Request-Id") = %q; want a random UUID`, id)
+			}
+			if got, want := md["x-request-id"], []string{id}; !reflect.DeepEqual(got, want) {
+				t.Errorf(`md["x-request-id"] = %q; want %q`, got, want)
+			}
+			if ctxID != id {
+				t.Errorf("runtime.RequestIDFromContext(ctx) = %q; want %q", ctxID, id)
+			}
+
+			var body struct {
+				Details []struct {
+					TypeURL string `json:"type_url"`
+				} `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("json.Unmarshal(%q, &body) failed with %v; want success", w.Body.String(), err)
+			}
+			if len(body.Details) != 1 || body.Details[0].TypeURL != "type.googleapis.com/google.rpc.RequestInfo" {
+				t.Errorf("body.Details = %+v; want one google.rpc.RequestInfo", body.Details)
+			}
+		})
+	}
+}
+
+func TestDefaultHTTPErrorWithRequestID(t *testing.T) {
+	for _, spec := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "added",
+			err:  status.Error(codes.NotFound, "not found"),
+			want: "abc-123",
+		},
+		{
+			name: "from server",
+			err: func() error {
+				s, _ := status.New(codes.NotFound, "not found").WithDetails(&errdetails.RequestInfo{RequestId: "server-id"})
+				return s.Err()
+			}(),
+			want: "server-id",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/foo", nil)
+			ctx := runtime.NewServerMetadataContext(runtime.NewRequestIDContext(context.Background(), "abc-123"), runtime.ServerMetadata{})
+			w := httptest.NewRecorder()
+			runtime.DefaultHTTPError(ctx, runtime.NewServeMux(), &runtime.JSONBuiltin{}, w, req, spec.err)
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("json.Unmarshal(%q, &body) failed with %v; want success", w.Body.String(), err)
+			}
+			if got := body["requestId"]; got != spec.want {
+				t.Errorf(`body["requestId"] = %v; want %q`, got, spec.want)
+			}
+			if got, want := len(body["details"].([]interface{})), 1; got != want {
+				t.Errorf(`len(body["details"]) = %d; want %d`, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// MetadataHTTPStatus is the header metadata key with which gRPC servers set
+	// the HTTP status code of successful responses, e.g. "201".
+	// It is not forwarded to clients as a header.
+	MetadataHTTPStatus = "grpcgateway-http-status"
+	// MetadataHTTPLocation is the header metadata key with which gRPC servers set
+	// the Location header of responses, e.g. for "201 Created" or redirects.
+	// It is not forwarded to clients as a Grpc-Metadata-* header.
+	MetadataHTTPLocation = "grpcgateway-http-location"
+)
+
+// isReservedResponseMetadata returns true if the header metadata "key" is interpreted by the gateway
+// rather than forwarded to clients.
+func isReservedResponseMetadata(key string) bool {
+	return key == MetadataHTTPStatus || key == MetadataHTTPLocation || key == MetadataSetCookie
+}
+
+// responseStatusFromMetadata returns the HTTP status code set by the gRPC server with MetadataHTTPStatus,
+// or 0 if it is absent or invalid.
+func responseStatusFromMetadata(ctx context.Context, mux *ServeMux, md ServerMetadata) int {
+	vals := md.HeaderMD[MetadataHTTPStatus]
+	if len(vals) == 0 {
+		return 0
+	}
+	code, err := strconv.Atoi(vals[0])
+	if err != nil || code < 200 || code > 599 {
+		mux.Logger().Warningf(ctx, "Invalid HTTP status in %s metadata: %q", MetadataHTTPStatus, vals[0])
+		return 0
+	}
+	return code
+}
+
+// forwardResponseLocation sets the Location header of "w" to the value set by the gRPC server with MetadataHTTPLocation.
+func forwardResponseLocation(w http.ResponseWriter, md ServerMetadata) {
+	if vals := md.HeaderMD[MetadataHTTPLocation]; len(vals) > 0 {
+		w.Header().Set("Location", vals[0])
+	}
+}
+
+// bodyAllowedForStatus reports whether a response with the status code "code" may have a body.
+func bodyAllowedForStatus(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,107 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	pb "github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestForwardResponseMessageWithHTTPStatusMetadata(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		header metadata.MD
+
+		wantStatus   int
+		wantLocation string
+		wantBody     bool
+	}{
+		{
+			name:       "default",
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+		{
+			name: "created",
+			header: metadata.Pairs(
+				runtime.MetadataHTTPStatus, "201",
+				runtime.MetadataHTTPLocation, "/v1/example/foo",
+			),
+			wantStatus:   http.StatusCreated,
+			wantLocation: "/v1/example/foo",
+			wantBody:     true,
+		},
+		{
+			name:       "no content",
+			header:     metadata.Pairs(runtime.MetadataHTTPStatus, "204"),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "redirect",
+			header: metadata.Pairs(
+				runtime.MetadataHTTPStatus, "302",
+				runtime.MetadataHTTPLocation, "https://example.org/",
+			),
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://example.org/",
+			wantBody:     true,
+		},
+		{
+			name:       "invalid",
+			header:     metadata.Pairs(runtime.MetadataHTTPStatus, "abc"),
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			header := metadata.Join(spec.header, metadata.Pairs("foo", "bar"))
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: header})
+			req := httptest.NewRequest("POST", "http://example.com/v1/example", nil)
+			w := httptest.NewRecorder()
+
+			runtime.ForwardResponseMessage(ctx, runtime.NewServeMux(), &runtime.JSONPb{}, w, req, &pb.SimpleMessage{Id: "foo"})
+
+			if got, want := w.Code, spec.wantStatus; got != want {
+				t.Errorf("w.Code = %d; want %d", got, want)
+			}
+			if got, want := w.Header().Get("Location"), spec.wantLocation; got != want {
+				t.Errorf("Location = %q; want %q", got, want)
+			}
+			if got := w.Body.Len() > 0; got != spec.wantBody {
+				t.Errorf("w.Body = %q; want body %v", w.Body.String(), spec.wantBody)
+			}
+			if !spec.wantBody && w.Header().Get("Content-Type") != "" {
+				t.Errorf("Content-Type = %q; want no Content-Type without body", w.Header().Get("Content-Type"))
+			}
+			if got, want := w.Header().Get("Grpc-Metadata-Foo"), "bar"; got != want {
+				t.Errorf("Grpc-Metadata-Foo = %q; want %q", got, want)
+			}
+			for _, key := range []string{runtime.MetadataHTTPStatus, runtime.MetadataHTTPLocation} {
+				if got := w.Header().Get(runtime.MetadataHeaderPrefix + key); got != "" {
+					t.Errorf("%s%s = %q; want the reserved metadata to be stripped", runtime.MetadataHeaderPrefix, key, got)
+				}
+			}
+		})
+	}
+}
+
+func TestForwardResponseMessageWithHTTPStatusMetadataAndEmptyBody(t *testing.T) {
+	header := metadata.Pairs(runtime.MetadataHTTPStatus, "201")
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: header})
+	req := httptest.NewRequest("POST", "http://example.com/v1/example", nil)
+	w := httptest.NewRecorder()
+
+	runtime.ForwardResponseMessage(ctx, runtime.NewServeMux(), &runtime.ProtoMarshaller{}, w, req, &empty.Empty{})
+
+	if got, want := w.Code, http.StatusCreated; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+	if got := w.Body.Len(); got != 0 {
+		t.Errorf("w.Body = %q; want empty", w.Body.String())
+	}
+}
@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SchemaValidator collects violations of the JSON schema constraints declared with
+// the openapiv2_field and openapiv2_schema options.
+// protoc-gen-grpc-gateway generates code which uses it when it runs with generate_schema_validation=true.
+//
+// Proto3 does not distinguish zero values from absent fields, so the constraints apply to zero values as well.
+// Being absent matters only to "required".
+type SchemaValidator struct {
+	violations []*errdetails.BadRequest_FieldViolation
+}
+
+// MustCompileSchemaPattern compiles "expr" of a "pattern" constraint.
+// It panics if "expr" is not a valid regular expression.
+func MustCompileSchemaPattern(expr string) *regexp.Regexp {
+	return regexp.MustCompile(expr)
+}
+
+func (v *SchemaValidator) addViolation(field, format string, args ...interface{}) {
+	v.violations = append(v.violations, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+// Required checks a field listed in "required".
+func (v *SchemaValidator) Required(field string, present bool) {
+	if !present {
+		v.addViolation(field, "value is required")
+	}
+}
+
+// Items checks the number of the elements "n" of a repeated field against "min_items" and "max_items".
+// 0 means no constraint.
+func (v *SchemaValidator) Items(field string, n int, min, max uint64) {
+	if min > 0 && uint64(n) < min {
+		v.addViolation(field, "value must contain at least %d item(s)", min)
+	}
+	if max > 0 && uint64(n) > max {
+		v.addViolation(field, "value must contain no more than %d item(s)", max)
+	}
+}
+
+// Length checks the number of the characters of "value" against "min_length" and "max_length".
+// 0 means no constraint.
+func (v *SchemaValidator) Length(field, value string, min, max uint64) {
+	n := uint64(utf8.RuneCountInString(value))
+	if min > 0 && n < min {
+		v.addViolation(field, "value length must be at least %d characters", min)
+	}
+	if max > 0 && n > max {
+		v.addViolation(field, "value length must be at most %d characters", max)
+	}
+}
+
+// Pattern checks "value" against "pattern".
+func (v *SchemaValidator) Pattern(field, value string, re *regexp.Regexp) {
+	if !re.MatchString(value) {
+		v.addViolation(field, "value does not match regex pattern %q", re.String())
+	}
+}
+
+// Minimum checks "value" against "minimum" and "exclusive_minimum".
+func (v *SchemaValidator) Minimum(field string, value, min float64, exclusive bool) {
+	v.minimum(field, value < min, value == min, min, exclusive)
+}
+
+// MinimumInt64 is Minimum for signed integer fields. It does not lose the precision of int64 in float64.
+func (v *SchemaValidator) MinimumInt64(field string, value, min int64, exclusive bool) {
+	v.minimum(field, value < min, value == min, min, exclusive)
+}
+
+// MinimumUint64 is Minimum for unsigned integer fields. It does not lose the precision of uint64 in float64.
+func (v *SchemaValidator) MinimumUint64(field string, value, min uint64, exclusive bool) {
+	v.minimum(field, value < min, value == min, min, exclusive)
+}
+
+func (v *SchemaValidator) minimum(field string, less, equal bool, min interface{}, exclusive bool) {
+	switch {
+	case exclusive && (less || equal):
+		v.addViolation(field, "value must be greater than %v", min)
+	case !exclusive && less:
+		v.addViolation(field, "value must be greater than or equal to %v", min)
+	}
+}
+
+// Maximum checks "value" against "maximum" and "exclusive_maximum".
+func (v *SchemaValidator) Maximum(field string, value, max float64, exclusive bool) {
+	v.maximum(field, value > max, value == max, max, exclusive)
+}
+
+// MaximumInt64 is Maximum for signed integer fields. It does not lose the precision of int64 in float64.
+func (v *SchemaValidator) MaximumInt64(field string, value, max int64, exclusive bool) {
+	v.maximum(field, value > max, value == max, max, exclusive)
+}
+
+// MaximumUint64 is Maximum for unsigned integer fields. It does not lose the precision of uint64 in float64.
+func (v *SchemaValidator) MaximumUint64(field string, value, max uint64, exclusive bool) {
+	v.maximum(field, value > max, value == max, max, exclusive)
+}
+
+func (v *SchemaValidator) maximum(field string, greater, equal bool, max interface{}, exclusive bool) {
+	switch {
+	case exclusive && (greater || equal):
+		v.addViolation(field, "value must be less than %v", max)
+	case !exclusive && greater:
+		v.addViolation(field, "value must be less than or equal to %v", max)
+	}
+}
+
+// Err returns an InvalidArgument status with google.rpc.BadRequest details which lists the violations,
+// or nil if there is no violation.
+func (v *SchemaValidator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	first := v.violations[0]
+	s := status.New(codes.InvalidArgument, fmt.Sprintf("invalid %s: %s", first.Field, first.Description))
+	ds, err := s.WithDetails(&errdetails.BadRequest{FieldViolations: v.violations})
+	if err != nil {
+		return s.Err()
+	}
+	return ds.Err()
+}
@@ -0,0 +1,125 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	pattern := runtime.MustCompileSchemaPattern(`^[a-z]+$`)
+	for _, spec := range []struct {
+		name       string
+		check      func(v *runtime.SchemaValidator)
+		wantFields []string
+	}{
+		{
+			name:  "no violation",
+			check: func(v *runtime.SchemaValidator) {},
+		},
+		{
+			name: "required",
+			check: func(v *runtime.SchemaValidator) {
+				v.Required("id", true)
+				v.Required("name", false)
+			},
+			wantFields: []string{"name"},
+		},
+		{
+			name: "items",
+			check: func(v *runtime.SchemaValidator) {
+				v.Items("few", 1, 2, 0)
+				v.Items("many", 3, 0, 2)
+				v.Items("empty", 0, 2, 0)
+				v.Items("ok", 2, 1, 2)
+			},
+			wantFields: []string{"few", "many", "empty"},
+		},
+		{
+			name: "length",
+			check: func(v *runtime.SchemaValidator) {
+				v.Length("short", "ab", 3, 0)
+				v.Length("long", "abcd", 0, 3)
+				v.Length("runes", "日本語", 3, 3)
+				v.Length("empty", "", 3, 0)
+			},
+			wantFields: []string{"short", "long", "empty"},
+		},
+		{
+			name: "pattern",
+			check: func(v *runtime.SchemaValidator) {
+				v.Pattern("match", "abc", pattern)
+				v.Pattern("mismatch", "ABC", pattern)
+				v.Pattern("empty", "", pattern)
+			},
+			wantFields: []string{"mismatch", "empty"},
+		},
+		{
+			name: "range",
+			check: func(v *runtime.SchemaValidator) {
+				v.Minimum("min", 1, 1, false)
+				v.Minimum("exclusive_min", 1, 1, true)
+				v.Maximum("max", 10, 10, false)
+				v.Maximum("exclusive_max", 10, 10, true)
+				v.Maximum("too_large", 11, 10, false)
+				v.Minimum("zero", 0, 1, false)
+				v.Maximum("negative_max", 0, -1, false)
+			},
+			wantFields: []string{"exclusive_min", "exclusive_max", "too_large", "zero", "negative_max"},
+		},
+		{
+			name: "integer range",
+			check: func(v *runtime.SchemaValidator) {
+				// 1<<53 + 1 is equal to 1<<53 in float64.
+				v.MinimumInt64("int64", 1<<53, 1<<53+1, false)
+				v.MaximumInt64("int64_ok", -1<<53-1, -1<<53-1, false)
+				v.MinimumUint64("uint64_ok", 1<<63+1, 1<<63+1, false)
+				v.MaximumUint64("uint64", 1<<63+1, 1<<63, true)
+			},
+			wantFields: []string{"int64", "uint64"},
+		},
+		{
+			name: "nested",
+			check: func(v *runtime.SchemaValidator) {
+				v.Required("nested.id", false)
+			},
+			wantFields: []string{"nested.id"},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			var v runtime.SchemaValidator
+			spec.check(&v)
+			err := v.Err()
+			if len(spec.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("v.Err() = %v; want nil", err)
+				}
+				return
+			}
+			s, ok := status.FromError(err)
+			if !ok || s.Code() != codes.InvalidArgument {
+				t.Fatalf("v.Err() = %v; want an InvalidArgument status", err)
+			}
+			var fields []string
+			for _, d := range s.Details() {
+				if br, ok := d.(*errdetails.BadRequest); ok {
+					for _, fv := range br.GetFieldViolations() {
+						fields = append(fields, fv.GetField())
+					}
+				}
+			}
+			if len(fields) != len(spec.wantFields) {
+				t.Fatalf("violations of %q; want %q", fields, spec.wantFields)
+			}
+			for i, f := range fields {
+				if f != spec.wantFields[i] {
+					t.Errorf("violations of %q; want %q", fields, spec.wantFields)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/status"
+)
+
+// The W3C Trace Context and Baggage headers, which AnnotateContext forwards as metadata with the lower-case keys.
+const (
+	traceparent = "Traceparent"
+	tracestate  = "Tracestate"
+	baggage     = "Baggage"
+)
+
+// maxBaggageSize is the limit on the size of the baggage in the W3C Baggage specification.
+const maxBaggageSize = 8192
+
+// TraceContext is the W3C Trace Context and Baggage of a request.
+type TraceContext struct {
+	// TraceParent is the value of the "traceparent" header, e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+	TraceParent string
+	// TraceState is the value of the "tracestate" header.
+	TraceState string
+	// Baggage is the value of the "baggage" header.
+	Baggage string
+}
+
+// pairs returns the metadata pairs which carry "tc" to the gRPC server.
+func (tc TraceContext) pairs() []string {
+	if tc.TraceParent == "" {
+		return nil
+	}
+	pairs := []string{strings.ToLower(traceparent), tc.TraceParent}
+	if tc.TraceState != "" {
+		pairs = append(pairs, strings.ToLower(tracestate), tc.TraceState)
+	}
+	if tc.Baggage != "" {
+		pairs = append(pairs, strings.ToLower(baggage), tc.Baggage)
+	}
+	return pairs
+}
+
+// SpanInfo describes the RPC a span is started for.
+type SpanInfo struct {
+	// HTTPMethod is the HTTP method of the route, e.g. "GET".
+	HTTPMethod string
+	// Pattern is the path template of the route, e.g. "/v1/{name=messages/*}".
+	Pattern string
+	// RPCMethod is the full name of the RPC method, e.g. "/pkg.Service/Method".
+	RPCMethod string
+}
+
+// Tracer is the interface through which a tracing library traces the RPCs made by the gateway.
+type Tracer interface {
+	// StartSpan is called by AnnotateContext before each RPC with the trace context of the request,
+	// which is zero if the request has no valid "traceparent" header.
+	// It returns the context for the span and the trace context to forward to the gRPC server,
+	// e.g. with the span as the parent. If the returned TraceParent is empty, "parent" is forwarded.
+	StartSpan(ctx context.Context, span SpanInfo, parent TraceContext) (context.Context, TraceContext)
+	// EndSpan is called with the context returned by StartSpan and the status of the RPC after the response is written.
+	EndSpan(ctx context.Context, span SpanInfo, s *status.Status)
+}
+
+// WithTracer returns a ServeMuxOption which makes ServeMux call "t" around each RPC.
+//
+// The status passed to EndSpan is the one given to HTTPErrorDetailsFromStatus by the error handlers,
+// or to the error handler of response streams. It is OK if the RPC succeeded, and Internal if the handler panicked.
+func WithTracer(t Tracer) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.tracer = t
+	}
+}
+
+// traceContextFromRequest returns the W3C trace context in the headers of "req".
+// The context is zero if the "traceparent" header is missing or invalid.
+func traceContextFromRequest(req *http.Request) TraceContext {
+	tp := req.Header[traceparent]
+	if len(tp) != 1 || !isValidTraceParent(tp[0]) {
+		return TraceContext{}
+	}
+	tc := TraceContext{
+		TraceParent: tp[0],
+		TraceState:  strings.Join(req.Header[tracestate], ","),
+	}
+	if b := strings.Join(req.Header[baggage], ","); len(b) <= maxBaggageSize {
+		tc.Baggage = b
+	}
+	return tc
+}
+
+// isValidTraceParent returns true if "tp" is a valid "traceparent" header, i.e.
+// "version-traceid-parentid-flags" in lower-case hex with non-zero IDs.
+// Versions newer than "00" may append fields after another "-".
+func isValidTraceParent(tp string) bool {
+	const size = 2 + 1 + 32 + 1 + 16 + 1 + 2
+	if len(tp) < size {
+		return false
+	}
+	version, traceID, parentID, flags := tp[0:2], tp[3:35], tp[36:52], tp[53:55]
+	if tp[2] != '-' || tp[35] != '-' || tp[52] != '-' {
+		return false
+	}
+	if !isLowerHex(version) || version == "ff" {
+		return false
+	}
+	if (version == "00" && len(tp) != size) || (len(tp) > size && tp[size] != '-') {
+		return false
+	}
+	return isLowerHex(traceID) && !isZeroHex(traceID) &&
+		isLowerHex(parentID) && !isZeroHex(parentID) &&
+		isLowerHex(flags)
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeroHex(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// startSpan starts the span of the RPC in "call" and returns the trace context to forward to the gRPC server.
+func (s *ServeMux) startSpan(ctx context.Context, call *rpcCall, parent TraceContext) (context.Context, TraceContext) {
+	if s.tracer == nil || call == nil || call.spanCtx != nil {
+		return ctx, parent
+	}
+	ctx, tc := s.tracer.StartSpan(ctx, call.span, parent)
+	call.spanCtx = ctx
+	if tc.TraceParent == "" {
+		return ctx, parent
+	}
+	return ctx, tc
+}
+
+// endSpan ends the span of the RPC in "call", if started.
+func (s *ServeMux) endSpan(call *rpcCall) {
+	if s.tracer == nil || call.spanCtx == nil {
+		return
+	}
+	s.tracer.EndSpan(call.spanCtx, call.span, call.status())
+}
@@ -0,0 +1,180 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/utilities"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testSpanParent  = "00-4bf92f3577b34da6a3ce929d0e0e4736-b7ad6b7169203331-01"
+)
+
+func TestAnnotateContextForwardsTraceContext(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		header http.Header
+		want   metadata.MD
+	}{
+		{
+			name: "valid",
+			header: http.Header{
+				"Traceparent": {testTraceParent},
+				"Tracestate":  {"congo=t61rcWkgMzE", "rojo=00f067aa0ba902b7"},
+				"Baggage":     {"userId=alice"},
+			},
+			want: metadata.MD{
+				"traceparent": {testTraceParent},
+				"tracestate":  {"congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"},
+				"baggage":     {"userId=alice"},
+			},
+		},
+		{
+			name: "future version",
+			header: http.Header{
+				"Traceparent": {"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-will-be"},
+			},
+			want: metadata.MD{
+				"traceparent": {"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-will-be"},
+			},
+		},
+		{
+			name: "zero trace id",
+			header: http.Header{
+				"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+				"Tracestate":  {"congo=t61rcWkgMzE"},
+			},
+		},
+		{
+			name: "upper case",
+			header: http.Header{
+				"Traceparent": {"00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01"},
+			},
+		},
+		{
+			name: "spoofed by metadata",
+			header: http.Header{
+				"Traceparent":               {testTraceParent},
+				"Grpc-Metadata-Traceparent": {"00-11111111111111111111111111111111-2222222222222222-01"},
+			},
+			want: metadata.MD{
+				"traceparent": {testTraceParent},
+			},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://example.com/foo", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest failed with %v; want success", err)
+			}
+			req.Header = spec.header
+
+			ctx, err := runtime.AnnotateContext(context.Background(), runtime.NewServeMux(), req)
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+			}
+			md, _ := metadata.FromOutgoingContext(ctx)
+			for _, k := range []string{"traceparent", "tracestate", "baggage"} {
+				if got, want := md[k], spec.want[k]; !reflect.DeepEqual(got, want) {
+					t.Errorf("md[%q] = %q; want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+type spanKey struct{}
+
+type testSpan struct {
+	info   runtime.SpanInfo
+	parent runtime.TraceContext
+	ended  bool
+	code   codes.Code
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(ctx context.Context, info runtime.SpanInfo, parent runtime.TraceContext) (context.Context, runtime.TraceContext) {
+	span := &testSpan{info: info, parent: parent}
+	t.spans = append(t.spans, span)
+	tc := parent
+	tc.TraceParent = testSpanParent
+	return context.WithValue(ctx, spanKey{}, span), tc
+}
+
+func (t *testTracer) EndSpan(ctx context.Context, info runtime.SpanInfo, s *status.Status) {
+	span := ctx.Value(spanKey{}).(*testSpan)
+	span.ended = true
+	span.code = s.Code()
+}
+
+func TestServeMuxWithTracer(t *testing.T) {
+	pat, err := runtime.NewPattern(1, []int{int(utilities.OpLitPush), 0, int(utilities.OpPush), 0, int(utilities.OpConcatN), 1, int(utilities.OpCapture), 1}, []string{"v1", "name"}, "")
+	if err != nil {
+		t.Fatalf("runtime.NewPattern failed with %v; want success", err)
+	}
+	for _, spec := range []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{name: "success", wantCode: codes.OK},
+		{name: "error", err: status.Error(codes.NotFound, "not found"), wantCode: codes.NotFound},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			tracer := new(testTracer)
+			mux := runtime.NewServeMux(runtime.WithTracer(tracer))
+			var md metadata.MD
+			mux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				ctx := runtime.NewRPCMethodContext(r.Context(), "/pkg.Service/Get")
+				ctx, err := runtime.AnnotateContext(ctx, mux, r)
+				if err != nil {
+					t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+				}
+				md, _ = metadata.FromOutgoingContext(ctx)
+				if spec.err != nil {
+					runtime.HTTPError(ctx, mux, &runtime.JSONBuiltin{}, w, r, spec.err)
+				}
+			})
+
+			req, err := http.NewRequest("GET", "http://example.com/v1/foo", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest failed with %v; want success", err)
+			}
+			req.Header.Set("Traceparent", testTraceParent)
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got, want := len(tracer.spans), 1; got != want {
+				t.Fatalf("len(tracer.spans) = %d; want %d", got, want)
+			}
+			span := tracer.spans[0]
+			wantInfo := runtime.SpanInfo{HTTPMethod: "GET", Pattern: "/v1/{name=*}", RPCMethod: "/pkg.Service/Get"}
+			if got := span.info; got != wantInfo {
+				t.Errorf("span.info = %+v; want %+v", got, wantInfo)
+			}
+			if got, want := span.parent.TraceParent, testTraceParent; got != want {
+				t.Errorf("span.parent.TraceParent = %q; want %q", got, want)
+			}
+			if !span.ended {
+				t.Errorf("span.ended = false; want true")
+			}
+			if got := span.code; got != spec.wantCode {
+				t.Errorf("span.code = %v; want %v", got, spec.wantCode)
+			}
+			if got, want := md["traceparent"], []string{testSpanParent}; !reflect.DeepEqual(got, want) {
+				t.Errorf(`md["traceparent"] = %q; want %q`, got, want)
+			}
+		})
+	}
+}
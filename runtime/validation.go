@@ -0,0 +1,121 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestValidatorFunc validates a request message populated by the generated handlers
+// before it is sent to the gRPC server.
+type RequestValidatorFunc func(ctx context.Context, req proto.Message) error
+
+// WithRequestValidation returns a ServeMuxOption which makes the generated handlers validate requests with "fn"
+// before sending them to the gRPC servers.
+// If "fn" is nil, DefaultRequestValidator is used.
+//
+// Errors which are not gRPC statuses are converted into InvalidArgument statuses with google.rpc.BadRequest details.
+// The field violations are taken from errors with Field() and Reason() methods, which protoc-gen-validate generates.
+func WithRequestValidation(fn RequestValidatorFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		if fn == nil {
+			fn = DefaultRequestValidator
+		}
+		serveMux.requestValidator = fn
+	}
+}
+
+// DefaultRequestValidator calls the Validate method of "req" if it has one.
+func DefaultRequestValidator(ctx context.Context, req proto.Message) error {
+	if v, ok := req.(interface {
+		Validate() error
+	}); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+type requestValidatorKey struct{}
+
+// ValidateRequest validates "req" with the validator of the ServeMux which "ctx" has been annotated by with AnnotateContext.
+// It does nothing if the ServeMux has no validator.
+//
+// The generated handlers call this after populating the request message.
+func ValidateRequest(ctx context.Context, req proto.Message) error {
+	fn, ok := ctx.Value(requestValidatorKey{}).(RequestValidatorFunc)
+	if !ok {
+		return nil
+	}
+	err := fn(ctx, req)
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return validationStatus(err).Err()
+}
+
+// fieldError is implemented by the validation errors generated by protoc-gen-validate.
+type fieldError interface {
+	error
+	Field() string
+	Reason() string
+}
+
+// validationStatus converts a validation error into an InvalidArgument status with field violations.
+func validationStatus(err error) *status.Status {
+	var violations []*errdetails.BadRequest_FieldViolation
+	errs := []error{err}
+	if m, ok := err.(interface {
+		AllErrors() []error
+	}); ok {
+		errs = m.AllErrors()
+	}
+	for _, e := range errs {
+		if v := fieldViolation(e); v != nil {
+			violations = append(violations, v)
+		}
+	}
+
+	s := status.New(codes.InvalidArgument, err.Error())
+	if len(violations) == 0 {
+		return s
+	}
+	ds, derr := s.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if derr != nil {
+		return s
+	}
+	return ds
+}
+
+// fieldViolation returns the field violation described by "err", following the causes of errors in embedded messages.
+func fieldViolation(err error) *errdetails.BadRequest_FieldViolation {
+	fe, ok := err.(fieldError)
+	if !ok {
+		return nil
+	}
+	var path []string
+	for {
+		path = append(path, fe.Field())
+		c, ok := fe.(interface {
+			Cause() error
+		})
+		if !ok {
+			break
+		}
+		cause, ok := c.Cause().(fieldError)
+		if !ok {
+			break
+		}
+		fe = cause
+	}
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       strings.Join(path, "."),
+		Description: fe.Reason(),
+	}
+}
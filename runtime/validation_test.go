@@ -0,0 +1,137 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/grpc-ecosystem/grpc-gateway/examples/proto/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validationError mimics the errors generated by protoc-gen-validate.
+type validationError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e validationError) Error() string  { return "invalid " + e.field + ": " + e.reason }
+func (e validationError) Field() string  { return e.field }
+func (e validationError) Reason() string { return e.reason }
+func (e validationError) Cause() error   { return e.cause }
+
+type validatedMessage struct {
+	*pb.SimpleMessage
+}
+
+func (m validatedMessage) Validate() error {
+	if m.Id == "" {
+		return validationError{field: "id", reason: "value is required"}
+	}
+	return nil
+}
+
+func annotateWithMux(t *testing.T, mux *runtime.ServeMux) context.Context {
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	ctx, err := runtime.AnnotateContext(context.Background(), mux, req)
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, mux, req) failed with %v; want success", err)
+	}
+	return ctx
+}
+
+func TestValidateRequest(t *testing.T) {
+	for _, spec := range []struct {
+		name      string
+		opts      []runtime.ServeMuxOption
+		req       proto.Message
+		wantCode  codes.Code
+		wantField string
+	}{
+		{
+			name:     "no validator",
+			req:      validatedMessage{&pb.SimpleMessage{}},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "default validator",
+			opts:     []runtime.ServeMuxOption{runtime.WithRequestValidation(nil)},
+			req:      validatedMessage{&pb.SimpleMessage{Id: "foo"}},
+			wantCode: codes.OK,
+		},
+		{
+			name:      "default validator failure",
+			opts:      []runtime.ServeMuxOption{runtime.WithRequestValidation(nil)},
+			req:       validatedMessage{&pb.SimpleMessage{}},
+			wantCode:  codes.InvalidArgument,
+			wantField: "id",
+		},
+		{
+			name:     "no Validate method",
+			opts:     []runtime.ServeMuxOption{runtime.WithRequestValidation(nil)},
+			req:      &pb.SimpleMessage{},
+			wantCode: codes.OK,
+		},
+		{
+			name: "embedded message",
+			opts: []runtime.ServeMuxOption{runtime.WithRequestValidation(func(ctx context.Context, req proto.Message) error {
+				return validationError{
+					field:  "nested",
+					reason: "embedded message failed validation",
+					cause:  validationError{field: "name", reason: "value length must be at least 1 runes"},
+				}
+			})},
+			req:       &pb.SimpleMessage{},
+			wantCode:  codes.InvalidArgument,
+			wantField: "nested.name",
+		},
+		{
+			name: "plain error",
+			opts: []runtime.ServeMuxOption{runtime.WithRequestValidation(func(ctx context.Context, req proto.Message) error {
+				return errors.New("invalid request")
+			})},
+			req:      &pb.SimpleMessage{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "status",
+			opts: []runtime.ServeMuxOption{runtime.WithRequestValidation(func(ctx context.Context, req proto.Message) error {
+				return status.Error(codes.FailedPrecondition, "not ready")
+			})},
+			req:      &pb.SimpleMessage{},
+			wantCode: codes.FailedPrecondition,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			ctx := annotateWithMux(t, runtime.NewServeMux(spec.opts...))
+			err := runtime.ValidateRequest(ctx, spec.req)
+			s, _ := status.FromError(err)
+			if got, want := s.Code(), spec.wantCode; got != want {
+				t.Fatalf("runtime.ValidateRequest(ctx, %v) = %v; want code %v", spec.req, err, want)
+			}
+
+			var violations []*errdetails.BadRequest_FieldViolation
+			for _, d := range s.Details() {
+				if br, ok := d.(*errdetails.BadRequest); ok {
+					violations = append(violations, br.GetFieldViolations()...)
+				}
+			}
+			if spec.wantField == "" {
+				if len(violations) != 0 {
+					t.Errorf("violations = %v; want none", violations)
+				}
+				return
+			}
+			if len(violations) != 1 || violations[0].GetField() != spec.wantField {
+				t.Errorf("violations = %v; want a violation of %q", violations, spec.wantField)
+			}
+		})
+	}
+}